@@ -90,6 +90,11 @@ func assignable(sch cue.Value, T interface{}) error {
 		gval = stripLeadNull(gval)
 
 		sk, gk := sval.IncompleteKind(), gval.IncompleteKind()
+		// Go floats are encoded as CUE number. The scalar subsumption check
+		// still applies, so a float schema is acceptable for them.
+		if gk == cue.NumberKind && sk == cue.FloatKind {
+			gk = sk
+		}
 		// strict equality _might_ be too restrictive? But it's better to start there
 		if sk != gk && gk != cue.TopKind {
 			errs[p.String()] = fmt.Errorf("%s: is kind %s in schema, but kind %s in Go type", p, sk, gk)
@@ -295,6 +300,15 @@ func structToMap(v cue.Value) map[string]valpath {
 	}
 
 	for iter.Next() {
+		// Embedded Go structs are encoded as a field with an empty label,
+		// but encoding/json promotes their fields into the embedding struct
+		if sel := iter.Selector(); sel.IsString() && sel.String() == `""` {
+			for k, vp := range structToMap(stripLeadNull(iter.Value())) {
+				m[k] = vp
+			}
+			continue
+		}
+
 		vp := valpath{
 			Path:  cue.MakePath(iter.Selector()),
 			Value: iter.Value(),
@@ -341,6 +341,49 @@ func TestAssignable(t *testing.T) {
 			}
 			`,
 		},
+		"float": {
+			T: &struct {
+				Float32 float32 `json:"float32"`
+				Float64 float64 `json:"float64"`
+			}{},
+			cue: `typ: {
+				float32: float
+				float64: float
+			}
+			`,
+		},
+		"floatMistype": {
+			T: &struct {
+				Float float64 `json:"float"`
+			}{},
+			cue: `typ: {
+				float: string
+			}
+			`,
+			invalid: true,
+		},
+		"embeddedStruct": {
+			T: &struct {
+				EmbedMe
+				Foo string `json:"foo"`
+			}{},
+			cue: `typ: {
+				foo: string
+				embedded: string
+			}
+			`,
+		},
+		"embeddedStructMissing": {
+			T: &struct {
+				EmbedMe
+				Foo string `json:"foo"`
+			}{},
+			cue: `typ: {
+				foo: string
+			}
+			`,
+			invalid: true,
+		},
 		"integerArch": {
 			T: &struct {
 				UintField uint `json:"uintField"`
@@ -385,6 +428,10 @@ func TestAssignable(t *testing.T) {
 	}
 }
 
+type EmbedMe struct {
+	Embedded string `json:"embedded"`
+}
+
 func TestNoDeepPointer(t *testing.T) {
 	typ := &struct{}{}
 	assignerr := assignable(cue.Value{}, &typ)
@@ -0,0 +1,155 @@
+package thema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// A Catalog is an index over many lineages, keyed by their names.
+//
+// Programs that work with many kinds of objects typically need to look up
+// schemas for a particular kind and version, or to determine which lineage an
+// incoming payload belongs to. Catalog provides these operations, so that
+// such programs need not each maintain their own registry of lineages.
+//
+// To build a Catalog of all the lineages in a CUE module, see
+// [github.com/grafana/thema/load.Catalog].
+type Catalog struct {
+	lins map[string]Lineage
+}
+
+// NewCatalog creates a Catalog from the provided lineages. An error is
+// returned if more than one lineage has the same name.
+func NewCatalog(lins ...Lineage) (*Catalog, error) {
+	cat := &Catalog{
+		lins: make(map[string]Lineage, len(lins)),
+	}
+	for _, lin := range lins {
+		if err := cat.add(lin); err != nil {
+			return nil, err
+		}
+	}
+	return cat, nil
+}
+
+func (cat *Catalog) add(lin Lineage) error {
+	isValidLineage(lin)
+	name := lin.Name()
+	if _, has := cat.lins[name]; has {
+		return fmt.Errorf("catalog already contains a lineage named %q", name)
+	}
+	cat.lins[name] = lin
+	return nil
+}
+
+// Names returns the names of all lineages in the catalog, in sorted order.
+func (cat *Catalog) Names() []string {
+	names := make([]string, 0, len(cat.lins))
+	for name := range cat.lins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lineages returns all lineages in the catalog, sorted by name.
+func (cat *Catalog) Lineages() []Lineage {
+	names := cat.Names()
+	lins := make([]Lineage, 0, len(names))
+	for _, name := range names {
+		lins = append(lins, cat.lins[name])
+	}
+	return lins
+}
+
+// Lineage returns the lineage in the catalog with the provided name.
+//
+// If no such lineage exists, the returned error wraps
+// [terrors.ErrLineageNotExist].
+func (cat *Catalog) Lineage(name string) (Lineage, error) {
+	lin, has := cat.lins[name]
+	if !has {
+		return nil, fmt.Errorf("%w: %q", terrors.ErrLineageNotExist, name)
+	}
+	return lin, nil
+}
+
+// Schema returns the schema identified by a reference of the form
+// name@version, where name is the name of a lineage in the catalog and
+// version is a syntactic version, optionally prefixed with "v" - e.g.
+// "ship@1.0" or "ship@v1.0".
+//
+// If no lineage exists with the referenced name, the returned error wraps
+// [terrors.ErrLineageNotExist]. If the lineage contains no schema with the
+// referenced version, the returned error wraps [terrors.ErrVersionNotExist].
+func (cat *Catalog) Schema(ref string) (Schema, error) {
+	name, vstr, has := strings.Cut(ref, "@")
+	if !has {
+		return nil, fmt.Errorf("schema reference %q must be of the form name@version", ref)
+	}
+
+	lin, err := cat.Lineage(name)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := ParseSyntacticVersion(strings.TrimPrefix(vstr, "v"))
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := lin.Schema(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", terrors.ErrVersionNotExist, err)
+	}
+	return sch, nil
+}
+
+// Detect determines the lineage in the catalog to which the provided data
+// belongs, based on the value of a discriminator field within the data, such
+// as "kind". The field is given as a CUE path expression, so nested
+// discriminators (e.g. "metadata.kind") are supported.
+//
+// The discriminator's value must be a string. A lineage whose name exactly
+// matches the value is preferred; otherwise, a lineage whose name matches
+// the value case-insensitively is chosen, provided there is only one.
+//
+// Detect does not validate the data. Call [Lineage.ValidateAny] on the
+// returned lineage to do so.
+func (cat *Catalog) Detect(data cue.Value, field string) (Lineage, error) {
+	p := cue.ParsePath(field)
+	if p.Err() != nil {
+		return nil, fmt.Errorf("%q is not a valid CUE path expression: %w", field, p.Err())
+	}
+
+	dv := data.LookupPath(p)
+	if !dv.Exists() {
+		return nil, fmt.Errorf("%w: discriminator field %q is absent from data", terrors.ErrValueNotExist, field)
+	}
+	disc, err := dv.String()
+	if err != nil {
+		return nil, fmt.Errorf("discriminator field %q must be a concrete string: %w", field, err)
+	}
+
+	if lin, has := cat.lins[disc]; has {
+		return lin, nil
+	}
+
+	var match Lineage
+	for _, name := range cat.Names() {
+		if strings.EqualFold(name, disc) {
+			if match != nil {
+				return nil, fmt.Errorf("discriminator value %q ambiguously matches lineages %q and %q", disc, match.Name(), name)
+			}
+			match = cat.lins[name]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %q (from discriminator field %q)", terrors.ErrLineageNotExist, disc, field)
+	}
+	return match, nil
+}
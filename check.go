@@ -0,0 +1,183 @@
+package thema
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/grafana/thema/compat"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// InvariantViolation describes a single way in which a candidate lineage fails
+// to uphold Thema's invariants.
+type InvariantViolation struct {
+	// Schemas contains the versions of the predecessor and successor schemas
+	// between which the invariant is violated. It is empty if the violation
+	// is not specific to a pair of schemas.
+	Schemas []SyntacticVersion
+
+	// Path is the path to the offending field, relative to the root of the
+	// successor schema. It is empty if the violation is not specific to a
+	// field.
+	Path []string
+
+	// Pos is the source position of the offending CUE. It may be invalid if
+	// no position could be resolved.
+	Pos token.Pos
+
+	// Message is a human-readable description of the violation.
+	Message string
+}
+
+func (v *InvariantViolation) Error() string {
+	var buf strings.Builder
+	if v.Pos.IsValid() {
+		fmt.Fprintf(&buf, "%s: ", v.Pos)
+	}
+	if len(v.Schemas) == 2 {
+		fmt.Fprintf(&buf, "%s -> %s: ", v.Schemas[0], v.Schemas[1])
+	}
+	if len(v.Path) > 0 {
+		fmt.Fprintf(&buf, "%s: ", strings.Join(v.Path, "."))
+	}
+	buf.WriteString(v.Message)
+	return buf.String()
+}
+
+// Unwrap implements standard Go error unwrapping, relied on by errors.Is.
+//
+// All InvariantViolations wrap the general ErrInvalidLineage sentinel error.
+func (v *InvariantViolation) Unwrap() error {
+	return terrors.ErrInvalidLineage
+}
+
+// CheckLineage checks the provided cue.Value against all of Thema's lineage
+// invariants, returning every violation found. An empty result indicates
+// that [BindLineage] will accept the lineage, when called with the same
+// BindOptions. As with BindLineage, [SkipBuggyChecks] skips checking backwards
+// [in]compatibility between schemas.
+//
+// Where BindLineage stops at the first violation, CheckLineage is intended for
+// reporting on lineages under development, e.g. in editors or CI. If the
+// candidate value is not structurally a lineage, only those structural
+// violations are reported, as checking further invariants is not possible.
+func CheckLineage(raw cue.Value, rt *Runtime, opts ...BindOption) []*InvariantViolation {
+	rt.l()
+	defer rt.u()
+
+	cfg := &bindConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if !raw.Exists() {
+		return []*InvariantViolation{{
+			Message: terrors.ErrValueNotExist.Error(),
+		}}
+	}
+
+	if err := raw.Validate(cue.Concrete(false)); err != nil {
+		return fromCUEErr(err)
+	}
+	if err := rt.linDef().Subsume(raw, cue.Raw(), cue.Schema(), cue.Final()); err != nil {
+		return fromCUEErr(err)
+	}
+
+	namev := raw.LookupPath(cue.MakePath(cue.Str("name")))
+	if _, err := namev.String(); err != nil {
+		return []*InvariantViolation{{
+			Pos:     namev.Pos(),
+			Message: "name field is not concrete",
+		}}
+	}
+
+	var vs []*InvariantViolation
+	var predecessor cue.Value
+	var predsv SyntacticVersion
+	seqiter, _ := raw.LookupPath(cue.MakePath(cue.Str("seqs"))).List()
+	var seqv uint
+	for seqiter.Next() {
+		var schv uint
+		schiter, _ := seqiter.Value().LookupPath(cue.MakePath(cue.Str("schemas"))).List()
+		for schiter.Next() {
+			v := synv(seqv, schv)
+			sch := schiter.Value()
+			if !(schv == 0 && seqv == 0) && !cfg.skipbuggychecks {
+				if cerr := checkCompat(raw, predecessor, sch, predsv, v); cerr != nil {
+					vs = append(vs, compatViolations(cerr, predecessor, sch)...)
+				}
+			}
+
+			predecessor = sch
+			predsv = v
+			schv++
+		}
+		seqv++
+	}
+
+	return vs
+}
+
+// fromCUEErr converts each of the errors in a CUE error list into an
+// InvariantViolation.
+func fromCUEErr(err error) []*InvariantViolation {
+	var vs []*InvariantViolation
+	for _, ee := range errors.Errors(err) {
+		pos := ee.Position()
+		if !pos.IsValid() {
+			if ipos := ee.InputPositions(); len(ipos) > 0 {
+				pos = ipos[0]
+			}
+		}
+		msg, args := ee.Msg()
+		vs = append(vs, &InvariantViolation{
+			Path:    ee.Path(),
+			Pos:     pos,
+			Message: fmt.Sprintf(msg, args...),
+		})
+	}
+	return vs
+}
+
+// compatViolations converts a compatInvariantError into InvariantViolations,
+// one for each breaking field-level change between the predecessor and
+// successor schemas.
+func compatViolations(cerr *compatInvariantError, predecessor, sch cue.Value) []*InvariantViolation {
+	schemas := cerr.violation[:]
+	if cerr.detail == nil {
+		return []*InvariantViolation{{
+			Schemas: schemas,
+			Pos:     sch.Pos(),
+			Message: fmt.Sprintf("schema %s must be backwards incompatible with schema %s, but is compatible", schemas[1], schemas[0]),
+		}}
+	}
+
+	var vs []*InvariantViolation
+	for _, c := range compat.Diff(predecessor, sch).Breaking() {
+		pos := c.Pos
+		if !pos.IsValid() {
+			pos = sch.Pos()
+		}
+		vs = append(vs, &InvariantViolation{
+			Schemas: schemas,
+			Path:    c.Path,
+			Pos:     pos,
+			Message: c.Description(),
+		})
+	}
+	if len(vs) == 0 {
+		// Couldn't narrow it down to any field, so fall back on the CUE error
+		vs = fromCUEErr(cerr.detail)
+		for _, v := range vs {
+			v.Schemas = schemas
+			v.Path = nil
+			if !v.Pos.IsValid() {
+				v.Pos = sch.Pos()
+			}
+		}
+	}
+	return vs
+}
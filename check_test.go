@@ -0,0 +1,145 @@
+package thema
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/grafana/thema/internal/envvars"
+)
+
+var badlinstr = `
+name: "bad"
+seqs: [
+	{
+		schemas: [
+			{
+				a: string
+				b: int
+			},
+			{
+				a: int
+				b: string
+				c: bool
+			},
+		]
+	},
+	{
+		schemas: [
+			{
+				a: int
+				b: string
+				c: bool
+			},
+		]
+
+		lens: forward: {
+			to:         seqs[1].schemas[0]
+			from:       seqs[0].schemas[1]
+			translated: to & rel
+			rel:        from
+			lacunas: []
+		}
+		lens: reverse: {
+			to:         seqs[0].schemas[1]
+			from:       seqs[1].schemas[0]
+			translated: to & rel
+			rel:        from
+			lacunas: []
+		}
+	},
+]
+`
+
+func TestCheckLineage(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(badlinstr, cue.Filename("bad.cue")))
+
+	if _, err := BindLineage(val, rt); err == nil {
+		t.Fatal("expected BindLineage to reject lineage")
+	}
+
+	vs := CheckLineage(val, rt)
+	expect := []struct {
+		schemas []SyntacticVersion
+		path    string
+		line    int
+	}{
+		{[]SyntacticVersion{synv(0, 0), synv(0, 1)}, "a", 11},
+		{[]SyntacticVersion{synv(0, 0), synv(0, 1)}, "b", 12},
+		{[]SyntacticVersion{synv(0, 0), synv(0, 1)}, "c", 13},
+		{[]SyntacticVersion{synv(0, 1), synv(1, 0)}, "", 0},
+	}
+	if len(vs) != len(expect) {
+		for _, v := range vs {
+			t.Log(v)
+		}
+		t.Fatalf("expected %d violations, got %d", len(expect), len(vs))
+	}
+
+	for i, ex := range expect {
+		v := vs[i]
+		if len(v.Schemas) != 2 || v.Schemas[0] != ex.schemas[0] || v.Schemas[1] != ex.schemas[1] {
+			t.Errorf("violation %d: expected schemas %v, got %v", i, ex.schemas, v.Schemas)
+		}
+		if p := strings.Join(v.Path, "."); p != ex.path {
+			t.Errorf("violation %d: expected path %q, got %q", i, ex.path, p)
+		}
+		if !v.Pos.IsValid() {
+			t.Errorf("violation %d: expected valid position", i)
+		} else if ex.line != 0 && (v.Pos.Filename() != "bad.cue" || v.Pos.Line() != ex.line) {
+			t.Errorf("violation %d: expected position bad.cue:%d, got %s", i, ex.line, v.Pos)
+		}
+		if !stderrors.Is(v, terrors.ErrInvalidLineage) {
+			t.Errorf("violation %d: expected to wrap ErrInvalidLineage", i)
+		}
+	}
+}
+
+func TestCheckLineageValid(t *testing.T) {
+	lin := transLin(t)
+	if vs := CheckLineage(lin.UnwrapCUE(), lin.Runtime()); len(vs) != 0 {
+		t.Fatalf("expected no violations in valid lineage, got %v", vs)
+	}
+}
+
+func TestCheckLineageDefaultChange(t *testing.T) {
+	linstr := `
+name: "defaults"
+seqs: [
+	{
+		schemas: [
+			{
+				a: string
+				b: int | *1
+			},
+			{
+				a: int
+				b: int | *2
+				c: bool
+			},
+		]
+	},
+]
+`
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(linstr))
+
+	vs := CheckLineage(val, rt)
+	paths := make(map[string]bool)
+	for _, v := range vs {
+		paths[strings.Join(v.Path, ".")] = true
+	}
+	for _, p := range []string{"a", "b", "c"} {
+		if !paths[p] {
+			t.Errorf("expected violation for field %s, got %v", p, vs)
+		}
+	}
+
+	if vs = CheckLineage(val, rt, SkipBuggyChecks()); len(vs) != 0 && !envvars.ForceVerify {
+		t.Errorf("expected no violations with SkipBuggyChecks, got %v", vs)
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"path/filepath"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/ast/astutil"
 	"github.com/grafana/thema"
+	tastutil "github.com/grafana/thema/internal/astutil"
 	"github.com/grafana/thema/vmux"
 	"github.com/spf13/cobra"
 )
@@ -24,24 +27,35 @@ func setupDataCommand(cmd *cobra.Command) {
 	validateCmd.MarkFlagRequired("version")
 	validateCmd.Flags().StringVarP(&encoding, "encoding", "e", "", "input data encoding. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
 	validateCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "emit no output, exit status only")
+	addStreamFlags(validateCmd)
 
 	dataCmd.AddCommand(validateAnyCmd)
 	validateAnyCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to validate data against")
 	validateAnyCmd.Flags().StringVarP(&encoding, "encoding", "e", "", "input data encoding. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
 	validateAnyCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "emit no output, exit status only")
+	addStreamFlags(validateAnyCmd)
 
 	dataCmd.AddCommand(translateCmd)
 	translateCmd.Flags().StringVarP((*string)(&verstr), "to", "v", "", "schema version to translate input data to")
 	translateCmd.MarkFlagRequired("to")
 	translateCmd.Flags().StringVarP(&encoding, "encoding", "e", "", "input data encoding. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	translateCmd.Flags().StringVarP(&transOutput, "output", "o", "json", "output encoding. \"json\", \"yaml\", or \"cue\".")
+	translateCmd.Flags().BoolVar(&resultOnly, "result-only", false, "output only the translated instance, writing any lacunas to stderr or --lacunas-file")
+	translateCmd.Flags().StringVar(&lacunasFile, "lacunas-file", "", "with --result-only, write lacunas as JSON to this file instead of stderr")
+	addStreamFlags(translateCmd)
 
 	dataCmd.AddCommand(hydrateCmd)
 	hydrateCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to validate data against")
 	hydrateCmd.Flags().StringVarP(&encoding, "encoding", "e", "", "input data encoding. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	addStreamFlags(hydrateCmd)
 
 	dataCmd.AddCommand(dehydrateCmd)
 	dehydrateCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to validate data against")
 	dehydrateCmd.Flags().StringVarP(&encoding, "encoding", "e", "", "input data encoding. Autodetected by default, but can be constrained to \"json\" or \"yaml\".")
+	addStreamFlags(dehydrateCmd)
+
+	mc := new(migrateCommand)
+	mc.setup(dataCmd)
 }
 
 var dataCmd = &cobra.Command{
@@ -59,8 +73,8 @@ data. All data operations are performed in the context of the provided lineage.
 Data may be provided on stdin, or by passing a single path to a file as an
 argument. Stdin is ignored if a path is provided. JSON and YAML inputs are
 supported; the correct encoding is inferred. Only one object instance may be
-validated per command invocation.
-`
+processed per command invocation, unless --stream is passed.
+` + dataStreamText
 
 var validateCmd = &cobra.Command{
 	Use:   "validate -l <lineage-fs-path> -v <synver> [-p <cue-path>] [-q] [-e <encoding>] [<data-fs-path>]",
@@ -73,18 +87,23 @@ Success outputs nothing and exits 0. Failure outputs the validation problem
 	Args:              cobra.MaximumNArgs(1),
 	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInput, validateDataInput),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stream {
+			return runStream(cmd, args, validateRecord)
+		}
 		if !datval.Exists() {
 			panic("datval does not exist")
 		}
 
-		_, err := sch.Validate(datval)
-		if err != nil {
-			return err
-		}
-		return nil
+		_, _, err := validateRecord(datval)
+		return err
 	},
 }
 
+func validateRecord(v cue.Value) (thema.SyntacticVersion, interface{}, error) {
+	_, err := sch.Validate(v)
+	return sch.Version(), nil, err
+}
+
 var validateAnyCmd = &cobra.Command{
 	Use:   "validate-any -l <lineage-fs-path> [-p <cue-path>] [-v <synver>] [-q] [-e <encoding>] [<data-fs-path>]",
 	Short: "Search a lineage for a schema that validates some input data",
@@ -100,34 +119,58 @@ be printed.
 	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInputOptional, validateDataInput),
 	Args:              cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stream {
+			return runStream(cmd, args, validateAnyRecord)
+		}
 		if !datval.Exists() {
 			panic("datval does not exist")
 		}
 
-		var reterr error
-		if sch != nil {
-			_, reterr = sch.Validate(datval)
-			if reterr == nil {
-				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", sch.Version())
-				return nil
+		sv, _, err := validateAnyRecord(datval)
+		if err != nil {
+			if errors.Is(err, errNoValidSchema) {
+				// Empty error should cause exit 1, but no output (or maybe just newline)
+				return errors.New("")
 			}
+			return err
 		}
-		inst := lin.ValidateAny(datval)
-		if inst != nil {
-			fmt.Fprintf(cmd.OutOrStdout(), "%s\n", inst.Schema().Version())
-			return nil
-		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", sv)
+		return nil
+	},
+}
+
+var errNoValidSchema = errors.New("input data is not valid for any schema in lineage")
 
-		if reterr != nil {
-			return reterr
+func validateAnyRecord(v cue.Value) (thema.SyntacticVersion, interface{}, error) {
+	var reterr error
+	if sch != nil {
+		_, reterr = sch.Validate(v)
+		if reterr == nil {
+			return sch.Version(), nil, nil
 		}
-		// Empty error should cause exit 1, but no output (or maybe just newline)
-		return errors.New("")
-	},
+	}
+	inst := lin.ValidateAny(v)
+	if inst != nil {
+		return inst.Schema().Version(), nil, nil
+	}
+
+	if reterr != nil {
+		return thema.SyntacticVersion{}, nil, reterr
+	}
+	return thema.SyntacticVersion{}, nil, errNoValidSchema
 }
 
+// output encoding for translate
+var transOutput string
+
+// whether translate outputs only the translated instance
+var resultOnly bool
+
+// file to which translate writes lacunas when resultOnly is set
+var lacunasFile string
+
 var translateCmd = &cobra.Command{
-	Use:   "translate -l <lineage-fs-path> [-p <cue-path>] [--to <synver>] [-e <encoding>] [<data-fs-path>]",
+	Use:   "translate -l <lineage-fs-path> [-p <cue-path>] [--to <synver>] [-e <encoding>] [-o <encoding>] [--result-only [--lacunas-file <path>]] [<data-fs-path>]",
 	Short: "Translate some valid input data from one schema to another",
 	Long: `Translate some valid input data from one schema to another.
 ` + dataReuseText + `
@@ -135,46 +178,143 @@ Success outputs the translated object instance, the version the input validated
 against, any emitted lacuna, and exits 0. Failure exits 1 with an informative
 error.
 
+Output is JSON by default; --output may instead select "yaml" or "cue". With
+--result-only, only the translated object instance is output, so that the
+command may be used directly in shell pipelines, e.g. to rewrite YAML config.
+Any emitted lacunas are then written as JSON to stderr, or to the file given by
+--lacunas-file. (Nothing is written to stderr if there are no lacunas; the
+lacunas file is always written.) Neither --output nor --result-only may be
+combined with --stream.
+
 Note that Thema's invariants (once finalized) guarantee that failures can only
 arise during data input decoding or validation, never during translation.
 `,
-	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInput, validateDataInput),
+	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInput, validateTranslateOutput, validateDataInput),
 	Args:              cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stream {
+			return runStream(cmd, args, translateRecord)
+		}
 		if !datval.Exists() {
 			panic("datval does not exist")
 		}
 
-		inst := lin.ValidateAny(datval)
-		if inst == nil {
-			return errors.New("input data is not valid for any schema in lineage")
-		}
-
-		// Prior validations checked that the schema version exists in the lineage
-		tinst, lac := inst.Translate(sch.Version())
-		if err := validateTranslationResult(tinst, lac); err != nil {
+		_, r, err := translateRecord(datval)
+		if err != nil {
 			return err
 		}
 
-		// TODO support non-JSON output
-		r := translationResult{
-			From:    inst.Schema().Version().String(),
-			To:      tinst.Schema().Version().String(),
-			Result:  tinst.UnwrapCUE(),
-			Lacunas: lac,
+		tr := r.(translationResult)
+		var out interface{} = tr
+		if resultOnly {
+			out = tr.Result
+			if err = writeLacunas(cmd, tr.Lacunas); err != nil {
+				return err
+			}
 		}
 
-		byt, err := json.MarshalIndent(r, "", "  ")
+		byt, err := encodeOutput(out, transOutput)
 		if err != nil {
-			return fmt.Errorf("error marshaling translation result to JSON: %w", err)
+			return fmt.Errorf("error encoding translation result to %s: %w", transOutput, err)
 		}
-		buf := bytes.NewBuffer(byt)
-		_, err = io.Copy(cmd.OutOrStdout(), buf)
-
+		_, err = cmd.OutOrStdout().Write(byt)
 		return err
 	},
 }
 
+func validateTranslateOutput(cmd *cobra.Command, args []string) error {
+	switch transOutput {
+	case "json", "yaml", "cue":
+	default:
+		return fmt.Errorf("unrecognized output encoding %q - must choose \"json\", \"yaml\" or \"cue\"", transOutput)
+	}
+	if stream && (transOutput != "json" || resultOnly) {
+		return errors.New("--output and --result-only cannot be used with --stream, which always outputs NDJSON")
+	}
+	if lacunasFile != "" && !resultOnly {
+		return errors.New("--lacunas-file may only be used with --result-only")
+	}
+	return nil
+}
+
+// writeLacunas writes the lacunas as JSON to the --lacunas-file, if one was
+// provided, or else to stderr if there are any.
+func writeLacunas(cmd *cobra.Command, lac thema.TranslationLacunas) error {
+	list := lac.AsList()
+	if list == nil {
+		list = []thema.Lacuna{}
+	}
+
+	if lacunasFile == "" {
+		if len(list) == 0 {
+			return nil
+		}
+		return printJSON(cmd.ErrOrStderr(), list)
+	}
+
+	f, err := os.Create(lacunasFile)
+	if err != nil {
+		return fmt.Errorf("could not create lacunas file: %w", err)
+	}
+	defer f.Close() // nolint: errcheck
+	return printJSON(f, list)
+}
+
+// encodeOutput encodes the provided value, which must be JSON-marshalable, in
+// the requested output encoding.
+func encodeOutput(v interface{}, enc string) ([]byte, error) {
+	byt, err := json.MarshalIndent(v, "", "  ")
+	if err != nil || enc == "json" {
+		return byt, err
+	}
+
+	// Round-trip through JSON so that only concrete data, and no schema
+	// constraints, remain in the value
+	cv, err := vmux.NewJSONEndec("output").Decode(rt.UnwrapCUE().Context(), byt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch enc {
+	case "yaml":
+		return vmux.NewYAMLEndec("output").Encode(cv)
+	case "cue":
+		n := cv.Syntax(cue.Final(), cue.Concrete(true))
+		if x, ok := n.(ast.Expr); ok {
+			// Emit structs as a file, without enclosing braces
+			if n, err = astutil.ToFile(x); err != nil {
+				return nil, err
+			}
+		}
+		return tastutil.FmtNode(n)
+	default:
+		return nil, fmt.Errorf("unrecognized output encoding %q", enc)
+	}
+}
+
+func translateRecord(v cue.Value) (thema.SyntacticVersion, interface{}, error) {
+	inst := lin.ValidateAny(v)
+	if inst == nil {
+		return thema.SyntacticVersion{}, nil, errNoValidSchema
+	}
+
+	// Prior validations checked that the schema version exists in the lineage
+	tinst, lac, err := inst.TranslateE(sch.Version())
+	if err != nil {
+		return thema.SyntacticVersion{}, nil, err
+	}
+	if err := validateTranslationResult(tinst, lac); err != nil {
+		return thema.SyntacticVersion{}, nil, err
+	}
+
+	return inst.Schema().Version(), translationResult{
+		From:    inst.Schema().Version().String(),
+		To:      tinst.Schema().Version().String(),
+		Result:  tinst.UnwrapCUE(),
+		Lacunas: lac,
+	}, nil
+}
+
 type translationResult struct {
 	From    string                   `json:"from"`
 	To      string                   `json:"to,omitempty"`
@@ -197,19 +337,22 @@ for validity against all schemas in the lineage.
 	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInputOptional, validateDataInput),
 	Args:              cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stream {
+			return runStream(cmd, args, hydrateRecord)
+		}
 		if !datval.Exists() {
 			panic("datval does not exist")
 		}
 
-		inst := lin.ValidateAny(datval)
-		if inst == nil {
-			return errors.New("input data is not valid for any schema in lineage")
+		_, hyd, err := hydrateRecord(datval)
+		if err != nil {
+			return err
 		}
 
 		// TODO support non-JSON output
-		byt, err := json.MarshalIndent(inst.Hydrate().UnwrapCUE(), "", "  ")
+		byt, err := json.MarshalIndent(hyd, "", "  ")
 		if err != nil {
-			fmt.Printf("%+v %#v\n", inst.Hydrate().UnwrapCUE(), inst.Hydrate().UnwrapCUE())
+			fmt.Printf("%+v %#v\n", hyd, hyd)
 			return fmt.Errorf("error marshaling hydrated object to JSON: %w", err)
 		}
 		buf := bytes.NewBuffer(byt)
@@ -219,6 +362,14 @@ for validity against all schemas in the lineage.
 	},
 }
 
+func hydrateRecord(v cue.Value) (thema.SyntacticVersion, interface{}, error) {
+	inst := lin.ValidateAny(v)
+	if inst == nil {
+		return thema.SyntacticVersion{}, nil, errNoValidSchema
+	}
+	return inst.Schema().Version(), inst.Hydrate().UnwrapCUE(), nil
+}
+
 var dehydrateCmd = &cobra.Command{
 	Use:   "dehydrate -l <lineage-fs-path> [-p <cue-path>] [-e <encoding>] [-v <synver>] [<data-fs-path>] ",
 	Short: "Remove all schema-specified defaults from some valid input data",
@@ -234,26 +385,20 @@ for validity against all schemas in the lineage.
 	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInputOptional, validateDataInput),
 	Args:              cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if stream {
+			return runStream(cmd, args, dehydrateRecord)
+		}
 		if !datval.Exists() {
 			panic("datval does not exist")
 		}
 
-		var inst *thema.Instance
-		var err error
-		if sch != nil {
-			inst, err = sch.Validate(datval)
-			if err != nil {
-				return err
-			}
-		} else {
-			inst = lin.ValidateAny(datval)
-			if inst == nil {
-				return errors.New("input data is not valid for any schema in lineage")
-			}
+		_, dehyd, err := dehydrateRecord(datval)
+		if err != nil {
+			return err
 		}
 
 		// TODO support non-JSON output
-		byt, err := json.MarshalIndent(inst.Dehydrate().UnwrapCUE(), "", "  ")
+		byt, err := json.MarshalIndent(dehyd, "", "  ")
 		if err != nil {
 			return fmt.Errorf("error marshaling dehydrated object to JSON: %w", err)
 		}
@@ -264,6 +409,23 @@ for validity against all schemas in the lineage.
 	},
 }
 
+func dehydrateRecord(v cue.Value) (thema.SyntacticVersion, interface{}, error) {
+	var inst *thema.Instance
+	var err error
+	if sch != nil {
+		inst, err = sch.Validate(v)
+		if err != nil {
+			return thema.SyntacticVersion{}, nil, err
+		}
+	} else {
+		inst = lin.ValidateAny(v)
+		if inst == nil {
+			return thema.SyntacticVersion{}, nil, errNoValidSchema
+		}
+	}
+	return inst.Schema().Version(), inst.Dehydrate().UnwrapCUE(), nil
+}
+
 func pathOrStdin(args []string) ([]byte, error) {
 	var byt []byte
 	switch len(args) {
@@ -309,6 +471,11 @@ func pathOrStdin(args []string) ([]byte, error) {
 }
 
 func validateDataInput(cmd *cobra.Command, args []string) error {
+	// Records are decoded one at a time as they are processed in stream mode
+	if stream {
+		return nil
+	}
+
 	var ext string
 
 	byt, err := pathOrStdin(args)
@@ -377,7 +544,7 @@ func validateDataInput(cmd *cobra.Command, args []string) error {
 // place
 func validateTranslationResult(tinst *thema.Instance, lac thema.TranslationLacunas) error {
 	if tinst == nil {
-		panic("unreachable, thema.Translate() should never return a nil instance")
+		panic("unreachable, thema.TranslateE() should never return a nil instance without an error")
 	}
 
 	raw := tinst.UnwrapCUE()
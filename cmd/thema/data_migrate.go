@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/vmux"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate -l <lineage-fs-path> [-p <cue-path>] --to <synver> [--dry-run] <dir>",
+	Short: "Translate all data files in a directory tree to a particular schema version",
+	Long: `Translate all data files in a directory tree to a particular schema version.
+
+Walk the provided directory, finding all JSON (.json) and YAML (.yaml, .yml)
+files. Hidden directories (e.g. .git) are skipped. For each file, detect the
+schema it is valid against, translate it to the --to version, and rewrite the
+file in place.
+
+Files are rewritten in their original encoding. Object key ordering is
+preserved for keys that exist both before and after translation, with new keys
+appended in schema order. Indentation is preserved for JSON, as are comments
+for YAML, but other formatting details may change. Files whose detected schema
+is already the --to version are left untouched.
+
+Each file's outcome, including any lacunas emitted by translation, is reported.
+With --dry-run, this report is produced without modifying any files.
+
+Exits 1 if any file could not be migrated, e.g. because it is not valid against
+any schema in the lineage. Such files do not stop the remaining files from
+being migrated.
+`,
+	Args:              cobra.ExactArgs(1),
+	PersistentPreRunE: mergeCobraefuncs(validateLineageInput, validateVersionInput),
+}
+
+type migrateCommand struct {
+	dryrun bool
+}
+
+func (mc *migrateCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVarP((*string)(&verstr), "to", "v", "", "schema version to migrate data files to")
+	migrateCmd.MarkFlagRequired("to")
+	migrateCmd.Flags().BoolVar(&mc.dryrun, "dry-run", false, "report what would be migrated without modifying any files")
+	migrateCmd.RunE = mc.run
+}
+
+// migrateStatus describes the outcome of migrating a single file.
+type migrateStatus int
+
+const (
+	migrateFailed migrateStatus = iota
+	migrateUnchanged
+	migrateMigrated
+)
+
+func (mc *migrateCommand) run(cmd *cobra.Command, args []string) error {
+	fi, err := os.Stat(args[0])
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", args[0])
+	}
+
+	out := cmd.OutOrStdout()
+	counts := make(map[migrateStatus]int)
+	err = filepath.WalkDir(args[0], func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != args[0] && (strings.HasPrefix(d.Name(), ".") || d.Name() == "cue.mod") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+
+		from, lac, err := mc.migrateFile(path)
+		switch {
+		case err != nil:
+			counts[migrateFailed]++
+			fmt.Fprintf(out, "%s: error: %s\n", path, err)
+		case lac == nil:
+			counts[migrateUnchanged]++
+			fmt.Fprintf(out, "%s: already at %s\n", path, sch.Version())
+		default:
+			counts[migrateMigrated]++
+			fmt.Fprintf(out, "%s: %s -> %s\n", path, from, sch.Version())
+			for _, l := range lac.AsList() {
+				fmt.Fprintf(out, "\tlacuna (%s): %s\n", l.Type, l.Message)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "migrated"
+	if mc.dryrun {
+		verb = "would be migrated"
+	}
+	summary := fmt.Sprintf("%d files %s, %d already at %s, %d failed", counts[migrateMigrated], verb, counts[migrateUnchanged], sch.Version(), counts[migrateFailed])
+	if counts[migrateFailed] > 0 {
+		return errors.New(summary)
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), summary)
+	return nil
+}
+
+// migrateFile translates the data in the file at the provided path to the
+// target schema, rewriting the file unless in dry run mode. The file's version
+// is detected via ValidateAny. A nil TranslationLacunas indicates the file was
+// detected as already being at the target version, and was not modified.
+func (mc *migrateCommand) migrateFile(path string) (thema.SyntacticVersion, thema.TranslationLacunas, error) {
+	var from thema.SyntacticVersion
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return from, nil, err
+	}
+
+	isyaml := filepath.Ext(path) != ".json"
+	var dec vmux.Decoder = vmux.NewJSONEndec(path)
+	if isyaml {
+		dec = vmux.NewYAMLEndec(path)
+	}
+	v, err := dec.Decode(rt.UnwrapCUE().Context(), b)
+	if err != nil {
+		return from, nil, err
+	}
+
+	inst := lin.ValidateAny(v)
+	if inst == nil {
+		return from, nil, errNoValidSchema
+	}
+	from = inst.Schema().Version()
+	if from == sch.Version() {
+		return from, nil, nil
+	}
+
+	tinst, lac, err := inst.TranslateE(sch.Version())
+	if err != nil {
+		return from, nil, err
+	}
+	if err = validateTranslationResult(tinst, lac); err != nil {
+		return from, nil, err
+	}
+
+	tb, err := tinst.UnwrapCUE().MarshalJSON()
+	if err != nil {
+		return from, nil, err
+	}
+	nb, err := reencodePreservingOrder(b, tb, isyaml)
+	if err != nil {
+		return from, nil, err
+	}
+
+	if !mc.dryrun {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return from, nil, err
+		}
+		if err = os.WriteFile(path, nb, fi.Mode().Perm()); err != nil {
+			return from, nil, err
+		}
+	}
+	return from, lac, nil
+}
+
+// reencodePreservingOrder encodes the translated JSON in the same encoding as
+// the original input, preserving the original's object key ordering and
+// formatting to the extent possible.
+//
+// Both inputs are parsed as YAML (of which JSON is a subset), so that the same
+// merge of key ordering applies to both encodings.
+func reencodePreservingOrder(orig, translated []byte, isyaml bool) ([]byte, error) {
+	var onode, tnode yaml.Node
+	if err := yaml.Unmarshal(orig, &onode); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(translated, &tnode); err != nil {
+		return nil, err
+	}
+	if len(onode.Content) != 1 || len(tnode.Content) != 1 {
+		return nil, errors.New("input must contain exactly one document")
+	}
+
+	clearStyle(tnode.Content[0])
+	onode.Content[0] = mergeNodeOrder(onode.Content[0], tnode.Content[0])
+
+	indent := detectIndent(orig)
+	buf := new(bytes.Buffer)
+	if isyaml {
+		enc := yaml.NewEncoder(buf)
+		if indent == "" || strings.Contains(indent, "\t") {
+			indent = "  "
+		}
+		enc.SetIndent(len(indent))
+		if err := enc.Encode(&onode); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), enc.Close()
+	}
+
+	if err := writeNodeJSON(buf, onode.Content[0]); err != nil {
+		return nil, err
+	}
+	if indent == "" && !bytes.Contains(bytes.TrimSpace(orig), []byte("\n")) {
+		// Original was compact, keep it that way
+		if bytes.HasSuffix(orig, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	}
+
+	out := new(bytes.Buffer)
+	if err := json.Indent(out, buf.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(orig, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// mergeNodeOrder returns the translated node, but with the ordering of mapping
+// keys, and the style and comments of nodes, taken from the original node
+// wherever the same key exists in both.
+func mergeNodeOrder(orig, trans *yaml.Node) *yaml.Node {
+	if orig.Kind != trans.Kind {
+		return trans
+	}
+
+	switch trans.Kind {
+	case yaml.ScalarNode:
+		if orig.Value == trans.Value && orig.ShortTag() == trans.ShortTag() {
+			return orig
+		}
+		trans.HeadComment, trans.LineComment, trans.FootComment = orig.HeadComment, orig.LineComment, orig.FootComment
+		return trans
+	case yaml.SequenceNode:
+		for i := range trans.Content {
+			if i < len(orig.Content) {
+				trans.Content[i] = mergeNodeOrder(orig.Content[i], trans.Content[i])
+			}
+		}
+	case yaml.MappingNode:
+		tidx := make(map[string]int, len(trans.Content)/2)
+		for i := 0; i < len(trans.Content); i += 2 {
+			tidx[trans.Content[i].Value] = i
+		}
+
+		content := make([]*yaml.Node, 0, len(trans.Content))
+		used := make(map[string]bool)
+		for i := 0; i < len(orig.Content); i += 2 {
+			k := orig.Content[i]
+			if ti, has := tidx[k.Value]; has {
+				content = append(content, k, mergeNodeOrder(orig.Content[i+1], trans.Content[ti+1]))
+				used[k.Value] = true
+			}
+		}
+		for i := 0; i < len(trans.Content); i += 2 {
+			if !used[trans.Content[i].Value] {
+				content = append(content, trans.Content[i], trans.Content[i+1])
+			}
+		}
+		trans.Content = content
+	default:
+		return trans
+	}
+
+	trans.Style = orig.Style
+	trans.HeadComment, trans.LineComment, trans.FootComment = orig.HeadComment, orig.LineComment, orig.FootComment
+	return trans
+}
+
+// clearStyle recursively resets node styles to the YAML encoder's defaults.
+// Nodes parsed from JSON are otherwise rendered as JSON-style flow YAML.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// writeNodeJSON writes the node as compact JSON, with object keys in node
+// order.
+func writeNodeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			kb, err := json.Marshal(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(kb)
+			buf.WriteByte(':')
+			if err = writeNodeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null", "!!bool", "!!int", "!!float":
+			buf.WriteString(n.Value)
+		default:
+			vb, err := json.Marshal(n.Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(vb)
+		}
+	default:
+		return fmt.Errorf("cannot represent YAML node of kind %v as JSON", n.Kind)
+	}
+	return nil
+}
+
+// detectIndent returns the leading whitespace of the first indented line in
+// the input, or the empty string if there is none.
+func detectIndent(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return ""
+}
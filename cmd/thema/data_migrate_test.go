@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"gopkg.in/yaml.v3"
+)
+
+const migrateLineage = `
+name: "ship"
+seqs: [
+	{
+		schemas: [
+			{ firstfield: string },
+		]
+	},
+	{
+		schemas: [
+			{ firstfield: string, secondfield: int },
+		]
+		lens: forward: {
+			from: seqs[0].schemas[0]
+			to: seqs[1].schemas[0]
+			rel: {
+				firstfield: from.firstfield
+				secondfield: -1
+			}
+			lacunas: []
+			translated: to & rel
+		}
+		lens: reverse: {
+			from: seqs[1].schemas[0]
+			to: seqs[0].schemas[0]
+			rel: firstfield: from.firstfield
+			lacunas: []
+			translated: to & rel
+		}
+	},
+]
+`
+
+func TestMigrateFileForward(t *testing.T) {
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(migrateLineage))
+	blin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin, sch = blin, thema.SchemaP(blin, thema.SV(1, 0))
+	t.Cleanup(func() { lin, sch = nil, nil })
+
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.json")
+	cur := filepath.Join(dir, "cur.json")
+	if err = os.WriteFile(old, []byte(`{"firstfield": "a"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	curb := []byte(`{"firstfield": "b", "secondfield": 2}` + "\n")
+	if err = os.WriteFile(cur, curb, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mc := &migrateCommand{}
+	from, lac, err := mc.migrateFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != thema.SV(0, 0) || lac == nil {
+		t.Fatalf("expected 0.0 file to be migrated, got from %s with lacunas %v", from, lac)
+	}
+	b, err := os.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"firstfield":"a","secondfield":-1}`+"\n" {
+		t.Fatalf("unexpected migrated file contents: %s", b)
+	}
+
+	from, lac, err = mc.migrateFile(cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != thema.SV(1, 0) || lac != nil {
+		t.Fatalf("expected 1.0 file to be left as-is, got from %s with lacunas %v", from, lac)
+	}
+	if b, _ = os.ReadFile(cur); string(b) != string(curb) {
+		t.Fatalf("file already at target version was modified: %s", b)
+	}
+}
+
+func TestReencodePreservingOrder(t *testing.T) {
+	tt := map[string]struct {
+		orig, translated, expected string
+		isyaml                     bool
+	}{
+		"jsonCompact": {
+			orig:       `{"b":1,"a":"x"}`,
+			translated: `{"a":"x","b":2,"c":true}`,
+			expected:   `{"b":2,"a":"x","c":true}`,
+		},
+		"jsonIndented": {
+			orig:       "{\n    \"b\": 1,\n    \"a\": \"x\"\n}\n",
+			translated: `{"a":"x","b":1}`,
+			expected:   "{\n    \"b\": 1,\n    \"a\": \"x\"\n}\n",
+		},
+		"yamlComments": {
+			orig:       "# head\nb: 1 # keep\na: x\n",
+			translated: `{"a":"x","b":1,"c":[1,2]}`,
+			expected:   "# head\nb: 1 # keep\na: x\nc:\n  - 1\n  - 2\n",
+			isyaml:     true,
+		},
+	}
+
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			b, err := reencodePreservingOrder([]byte(tc.orig), []byte(tc.translated), tc.isyaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tc.expected, b)
+			}
+		})
+	}
+}
+
+func TestMergeNodeOrder(t *testing.T) {
+	var orig, trans yaml.Node
+	if err := yaml.Unmarshal([]byte("z: 1 # comment\nnested: {y: 1, x: 2}\ndropped: true\n"), &orig); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte("added: 3\nnested: {x: 2, y: 1}\nz: 1\n"), &trans); err != nil {
+		t.Fatal(err)
+	}
+
+	merged := mergeNodeOrder(orig.Content[0], trans.Content[0])
+	var keys []string
+	for i := 0; i < len(merged.Content); i += 2 {
+		keys = append(keys, merged.Content[i].Value)
+	}
+	if len(keys) != 3 || keys[0] != "z" || keys[1] != "nested" || keys[2] != "added" {
+		t.Fatalf("expected original key order with new keys appended, got %v", keys)
+	}
+	if c := merged.Content[1].LineComment; c != "# comment" {
+		t.Errorf("expected comment to be retained, got %q", c)
+	}
+	nested := merged.Content[3]
+	if nested.Content[0].Value != "y" || nested.Content[2].Value != "x" {
+		t.Errorf("expected nested key order to be retained")
+	}
+	if nested.Style != yaml.FlowStyle {
+		t.Errorf("expected nested style to be retained")
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tt := map[string]string{
+		`{"a":1}`:                "",
+		"{\n  \"a\": 1\n}":       "  ",
+		"{\n\t\"a\": 1\n}":       "\t",
+		"a:\n    b: 1\n  c: 2\n": "    ",
+		"\n\n{\n   \"a\": 1\n}":  "   ",
+	}
+	for in, expected := range tt {
+		if got := detectIndent([]byte(in)); got != expected {
+			t.Errorf("detectIndent(%q): expected %q, got %q", in, expected, got)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	cjson "cuelang.org/go/encoding/json"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/vmux"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// stream mode, in which the input is a sequence of records
+var stream bool
+
+// whether to continue processing records in stream mode after one fails
+var continueOnError bool
+
+var dataStreamText = `
+With --stream, the input is instead treated as a sequence of records, either
+newline-delimited JSON (NDJSON) or a multi-document YAML stream. Each record is
+processed independently, and a result is written for each as a single line of
+JSON:
+
+  {"record":1,"ok":true,"version":"0.0", ...}
+  {"record":2,"ok":false,"error":"..."}
+
+Processing stops at the first failed record unless --continue-on-error is
+passed. A summary of record counts is written to stderr, and the command exits 1
+if any record failed.
+`
+
+func addStreamFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&stream, "stream", false, "treat input as a stream of NDJSON or multi-document YAML records")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "in stream mode, continue processing records after one fails")
+}
+
+// A recordFunc performs a data command's operation on a single input record,
+// returning the version of the schema the record was validated against, and
+// the command's result, if any.
+type recordFunc func(v cue.Value) (thema.SyntacticVersion, interface{}, error)
+
+// recordResult is the JSON representation of the result of processing a
+// single record in stream mode.
+type recordResult struct {
+	Record  int         `json:"record"`
+	OK      bool        `json:"ok"`
+	Version string      `json:"version,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// runStream applies the recordFunc to each record in the input, writing a
+// recordResult for each.
+func runStream(cmd *cobra.Command, args []string, f recordFunc) error {
+	r, closer, err := streamInput(args)
+	if err != nil {
+		return err
+	}
+	defer closer() // nolint: errcheck
+
+	next, err := recordReader(r, args)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetEscapeHTML(false)
+
+	var total, failed int
+	var stopped bool
+	for {
+		v, err := next()
+		if err == io.EOF {
+			break
+		}
+
+		total++
+		res := recordResult{Record: total}
+		// Decoding errors leave the decoder in an unknown state, so they
+		// always end the stream
+		decerr := err != nil
+		if !decerr {
+			var sv thema.SyntacticVersion
+			sv, res.Result, err = f(v)
+			if err == nil {
+				res.OK = true
+				res.Version = sv.String()
+			}
+		}
+		if err != nil {
+			failed++
+			res.Error = err.Error()
+		}
+
+		if !quiet {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		}
+		if decerr || (failed > 0 && !continueOnError) {
+			stopped = true
+			break
+		}
+	}
+
+	summary := fmt.Sprintf("%d records processed: %d succeeded, %d failed", total, total-failed, failed)
+	if failed > 0 {
+		if stopped {
+			summary += " (stopped early)"
+		}
+		return errors.New(summary)
+	}
+	if !quiet {
+		fmt.Fprintln(cmd.ErrOrStderr(), summary)
+	}
+	return nil
+}
+
+// streamInput opens the input, either a single path argument or stdin, for
+// streaming.
+func streamInput(args []string) (io.Reader, func() error, error) {
+	switch len(args) {
+	case 0:
+		fi, err := os.Stdin.Stat()
+		if err != nil {
+			return nil, nil, err
+		}
+		if fi.Mode()&os.ModeNamedPipe == 0 {
+			return nil, nil, errors.New("no path provided and nothing on stdin")
+		}
+		return os.Stdin, func() error { return nil }, nil
+	case 1:
+		f, err := os.Open(args[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open provided path: %w", err)
+		}
+		return f, f.Close, nil
+	default:
+		return nil, nil, errors.New("too many args: either provide path to input or pass input on stdin")
+	}
+}
+
+// recordReader returns a func that decodes successive records from the
+// provided reader, returning io.EOF when there are no more. The encoding is
+// taken from the --encoding flag, the input file extension, or else inferred
+// from the first non-whitespace byte of input.
+func recordReader(r io.Reader, args []string) (func() (cue.Value, error), error) {
+	br := bufio.NewReader(r)
+	enc := encoding
+	if enc == "" && len(args) == 1 {
+		switch filepath.Ext(args[0]) {
+		case ".json", ".ldjson", ".ndjson", ".jsonl":
+			enc = "json"
+		case ".yaml", ".yml":
+			enc = "yaml"
+		}
+	}
+	if enc == "" {
+		enc = "yaml"
+		for i := 1; ; i++ {
+			b, err := br.Peek(i)
+			if err != nil {
+				break
+			}
+			if c := b[i-1]; c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				if c == '{' || c == '[' {
+					enc = "json"
+				}
+				break
+			}
+		}
+	}
+
+	ctx := rt.UnwrapCUE().Context()
+	switch enc {
+	case "json":
+		dec := cjson.NewDecoder(nil, "stdin", br)
+		return func() (cue.Value, error) {
+			expr, err := dec.Extract()
+			if err != nil {
+				return cue.Value{}, err
+			}
+			return ctx.BuildExpr(expr), nil
+		}, nil
+	case "yaml":
+		dec := yaml.NewDecoder(br)
+		yd := vmux.NewYAMLEndec("stdin")
+		return func() (cue.Value, error) {
+			var node yaml.Node
+			if err := dec.Decode(&node); err != nil {
+				return cue.Value{}, err
+			}
+			b, err := yaml.Marshal(&node)
+			if err != nil {
+				return cue.Value{}, err
+			}
+			return yd.Decode(ctx, b)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown input encoding %q requested", encoding)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/spf13/cobra"
+)
+
+func TestRecordReaderEncoding(t *testing.T) {
+	tt := map[string]struct {
+		path, input string
+		// expected number of records decoded before EOF, or -1 if decoding
+		// the first record should fail
+		records int
+	}{
+		"ndjsonExt": {
+			path:    "in.ndjson",
+			input:   "{\"a\": 1}\n{\"a\": 2}\n",
+			records: 2,
+		},
+		"jsonlExt": {
+			path:    "in.jsonl",
+			input:   "{\"a\": 1}\n",
+			records: 1,
+		},
+		"jsonExtRejectsYAML": {
+			path:    "in.json",
+			input:   "a: 1\n",
+			records: -1,
+		},
+		"yamlExt": {
+			path:    "in.yaml",
+			input:   "a: 1\n---\na: 2\n---\na: 3\n",
+			records: 3,
+		},
+		"ymlExtAcceptsFlowMapping": {
+			path:    "in.yml",
+			input:   "{\"a\": 1}\n",
+			records: 1,
+		},
+		"sniffJSON": {
+			input:   " \t\r\n{\"a\": 1}\n{\"a\": 2}\n",
+			records: 2,
+		},
+		"sniffJSONList": {
+			input:   "\n[1, 2]\n",
+			records: 1,
+		},
+		"sniffYAML": {
+			input:   "\n\na: 1\n---\na: 2\n",
+			records: 2,
+		},
+		"sniffUnknownExt": {
+			path:    "in.txt",
+			input:   "a: 1\n",
+			records: 1,
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var args []string
+			if tc.path != "" {
+				args = []string{tc.path}
+			}
+			next, err := recordReader(strings.NewReader(tc.input), args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var n int
+			for {
+				v, err := next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if tc.records != -1 {
+						t.Fatalf("unexpected decoding error on record %d: %s", n+1, err)
+					}
+					return
+				}
+				if !v.Exists() {
+					t.Fatalf("record %d does not exist", n+1)
+				}
+				n++
+			}
+			if n != tc.records {
+				t.Fatalf("expected %d records, got %d", tc.records, n)
+			}
+		})
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(migrateLineage))
+	blin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin, sch = blin, thema.SchemaP(blin, thema.SV(0, 0))
+	t.Cleanup(func() {
+		lin, sch = nil, nil
+		quiet, continueOnError = false, false
+	})
+
+	const (
+		ndjsonInvalid = "{\"firstfield\": \"a\"}\n{\"firstfield\": 1}\n{\"firstfield\": \"c\"}\n"
+		yamlInvalid   = "firstfield: a\n---\nfirstfield: 1\n---\nfirstfield: c\n"
+	)
+
+	tt := map[string]struct {
+		path, input     string
+		continueOnError bool
+		quiet           bool
+		// expected outcome of each record written to stdout, e.g. "1:ok" or
+		// "2:fail"
+		records []string
+		err     string
+		stderr  string
+	}{
+		"ndjsonStop": {
+			path:    "in.ndjson",
+			input:   ndjsonInvalid,
+			records: []string{"1:ok", "2:fail"},
+			err:     "2 records processed: 1 succeeded, 1 failed (stopped early)",
+		},
+		"ndjsonContinue": {
+			path:            "in.ndjson",
+			input:           ndjsonInvalid,
+			continueOnError: true,
+			records:         []string{"1:ok", "2:fail", "3:ok"},
+			err:             "3 records processed: 2 succeeded, 1 failed",
+		},
+		"ndjsonQuiet": {
+			path:            "in.ndjson",
+			input:           ndjsonInvalid,
+			continueOnError: true,
+			quiet:           true,
+			err:             "3 records processed: 2 succeeded, 1 failed",
+		},
+		"ndjsonDecodeErrorStops": {
+			path:            "in.ndjson",
+			input:           "{\"firstfield\": \"a\"}\n{\"firstfield\": }\n{\"firstfield\": \"c\"}\n",
+			continueOnError: true,
+			records:         []string{"1:ok", "2:fail"},
+			err:             "2 records processed: 1 succeeded, 1 failed (stopped early)",
+		},
+		"ndjsonValid": {
+			path:    "in.ndjson",
+			input:   "{\"firstfield\": \"a\"}\n{\"firstfield\": \"b\"}\n",
+			records: []string{"1:ok", "2:ok"},
+			stderr:  "2 records processed: 2 succeeded, 0 failed\n",
+		},
+		"ndjsonValidQuiet": {
+			path:  "in.ndjson",
+			input: "{\"firstfield\": \"a\"}\n{\"firstfield\": \"b\"}\n",
+			quiet: true,
+		},
+		"yamlStop": {
+			path:    "in.yaml",
+			input:   yamlInvalid,
+			records: []string{"1:ok", "2:fail"},
+			err:     "2 records processed: 1 succeeded, 1 failed (stopped early)",
+		},
+		"yamlContinue": {
+			path:            "in.yaml",
+			input:           yamlInvalid,
+			continueOnError: true,
+			records:         []string{"1:ok", "2:fail", "3:ok"},
+			err:             "3 records processed: 2 succeeded, 1 failed",
+		},
+		"yamlDecodeErrorStops": {
+			path:            "in.yaml",
+			input:           "firstfield: a\n---\nfirstfield: [\n",
+			continueOnError: true,
+			records:         []string{"1:ok", "2:fail"},
+			err:             "2 records processed: 1 succeeded, 1 failed (stopped early)",
+		},
+		"sniffedYAMLContinue": {
+			path:            "in",
+			input:           yamlInvalid,
+			continueOnError: true,
+			records:         []string{"1:ok", "2:fail", "3:ok"},
+			err:             "3 records processed: 2 succeeded, 1 failed",
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			quiet, continueOnError = tc.quiet, tc.continueOnError
+
+			path := filepath.Join(t.TempDir(), tc.path)
+			if err := os.WriteFile(path, []byte(tc.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+
+			err := runStream(cmd, []string{path}, validateRecord)
+			if tc.err == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tc.err != "" && (err == nil || err.Error() != tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if stderr.String() != tc.stderr {
+				t.Fatalf("expected stderr %q, got %q", tc.stderr, stderr.String())
+			}
+
+			var got []string
+			sc := bufio.NewScanner(&stdout)
+			for sc.Scan() {
+				var res recordResult
+				if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+					t.Fatalf("output line is not a JSON record result: %s", sc.Text())
+				}
+				switch {
+				case res.OK && res.Version == "0.0" && res.Error == "":
+					got = append(got, fmt.Sprintf("%d:ok", res.Record))
+				case !res.OK && res.Version == "" && res.Error != "":
+					got = append(got, fmt.Sprintf("%d:fail", res.Record))
+				default:
+					t.Fatalf("inconsistent record result: %s", sc.Text())
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.records, " ") {
+				t.Fatalf("expected records %v, got %v", tc.records, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/vmux"
+	"github.com/spf13/cobra"
+)
+
+const translateLineage = `
+name: "trans"
+seqs: [
+	{
+		schemas: [
+			{ a: string },
+		]
+	},
+	{
+		schemas: [
+			{ a: string, b: int },
+		]
+		lens: forward: {
+			from: seqs[0].schemas[0]
+			to: seqs[1].schemas[0]
+			rel: {
+				a: from.a
+				b: -1
+			}
+			lacunas: [{
+				targetFields: [{ path: "b", value: -1 }]
+				message: "-1 used as a placeholder value"
+				type: { name: "Placeholder", id: 1 }
+			}]
+			translated: to & rel
+		}
+		lens: reverse: {
+			from: seqs[1].schemas[0]
+			to: seqs[0].schemas[0]
+			rel: a: from.a
+			lacunas: []
+			translated: to & rel
+		}
+	},
+]
+`
+
+const translateLacunas = `[
+  {
+    "targetFields": [
+      {
+        "path": "b",
+        "value": -1
+      }
+    ],
+    "type": "Placeholder",
+    "message": "-1 used as a placeholder value"
+  }
+]
+`
+
+func TestTranslateOutput(t *testing.T) {
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(translateLineage))
+	blin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lin, sch = blin, thema.SchemaP(blin, thema.SV(1, 0))
+	t.Cleanup(func() {
+		lin, sch, datval = nil, nil, cue.Value{}
+		transOutput, resultOnly, lacunasFile = "json", false, ""
+	})
+
+	tt := map[string]struct {
+		// input data, {"a": "x"} if empty
+		input      string
+		output     string
+		resultOnly bool
+		// if set, pass a --lacunas-file and expect these contents
+		lacunasFile    string
+		stdout, stderr string
+	}{
+		"json": {
+			output: "json",
+			stdout: `{
+  "from": "0.0",
+  "to": "1.0",
+  "result": {
+    "a": "x",
+    "b": -1
+  },
+  "lacunas": [
+    {
+      "v": [
+        1,
+        0
+      ],
+      "lacunas": [
+        {
+          "targetFields": [
+            {
+              "path": "b",
+              "value": -1
+            }
+          ],
+          "type": "Placeholder",
+          "message": "-1 used as a placeholder value"
+        }
+      ]
+    }
+  ]
+}`,
+		},
+		"yaml": {
+			output: "yaml",
+			stdout: `from: "0.0"
+to: "1.0"
+result:
+  a: x
+  b: -1
+lacunas:
+  - v:
+      - 1
+      - 0
+    lacunas:
+      - targetFields:
+          - path: b
+            value: -1
+        type: Placeholder
+        message: -1 used as a placeholder value
+`,
+		},
+		"cue": {
+			output: "cue",
+			stdout: `from: "0.0"
+to:   "1.0"
+result: {
+	a: "x"
+	b: -1
+}
+lacunas: [{
+	v: [1, 0]
+	lacunas: [{
+		targetFields: [{
+			path:  "b"
+			value: -1
+		}]
+		type:    "Placeholder"
+		message: "-1 used as a placeholder value"
+	}]
+}]
+`,
+		},
+		"jsonResultOnly": {
+			output:     "json",
+			resultOnly: true,
+			stdout: `{
+  "a": "x",
+  "b": -1
+}`,
+			stderr: translateLacunas,
+		},
+		"yamlResultOnly": {
+			output:     "yaml",
+			resultOnly: true,
+			stdout:     "a: x\nb: -1\n",
+			stderr:     translateLacunas,
+		},
+		"cueResultOnly": {
+			output:     "cue",
+			resultOnly: true,
+			stdout:     "a: \"x\"\nb: -1\n",
+			stderr:     translateLacunas,
+		},
+		"yamlResultOnlyLacunasFile": {
+			output:      "yaml",
+			resultOnly:  true,
+			lacunasFile: translateLacunas,
+			stdout:      "a: x\nb: -1\n",
+		},
+		"resultOnlyNoLacunas": {
+			input:      `{"a": "x", "b": 2}`,
+			output:     "yaml",
+			resultOnly: true,
+			stdout:     "a: x\nb: 2\n",
+		},
+		"resultOnlyNoLacunasFile": {
+			input:       `{"a": "x", "b": 2}`,
+			output:      "yaml",
+			resultOnly:  true,
+			lacunasFile: "[]\n",
+			stdout:      "a: x\nb: 2\n",
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			input := tc.input
+			if input == "" {
+				input = `{"a": "x"}`
+			}
+			var err error
+			datval, err = vmux.NewJSONEndec("stdin").Decode(ctx, []byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			transOutput, resultOnly, lacunasFile = tc.output, tc.resultOnly, ""
+			if tc.lacunasFile != "" {
+				lacunasFile = filepath.Join(t.TempDir(), "lacunas.json")
+			}
+			if err := validateTranslateOutput(nil, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			cmd.SetErr(&stderr)
+			if err := translateCmd.RunE(cmd, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if stdout.String() != tc.stdout {
+				t.Errorf("unexpected stdout:\n\twant: %q\n\tgot:  %q", tc.stdout, stdout.String())
+			}
+			if stderr.String() != tc.stderr {
+				t.Errorf("unexpected stderr:\n\twant: %q\n\tgot:  %q", tc.stderr, stderr.String())
+			}
+			if tc.lacunasFile != "" {
+				b, err := os.ReadFile(lacunasFile)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != tc.lacunasFile {
+					t.Errorf("unexpected lacunas file contents:\n\twant: %q\n\tgot:  %q", tc.lacunasFile, b)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTranslateOutput(t *testing.T) {
+	t.Cleanup(func() {
+		transOutput, resultOnly, lacunasFile, stream = "json", false, "", false
+	})
+
+	tt := map[string]struct {
+		output      string
+		resultOnly  bool
+		lacunasFile string
+		stream      bool
+		valid       bool
+	}{
+		"json":                  {output: "json", valid: true},
+		"yaml":                  {output: "yaml", valid: true},
+		"cue":                   {output: "cue", valid: true},
+		"unknown":               {output: "toml"},
+		"resultOnlyLacunasFile": {output: "json", resultOnly: true, lacunasFile: "lac.json", valid: true},
+		"lacunasFileOnly":       {output: "json", lacunasFile: "lac.json"},
+		"streamJSON":            {output: "json", stream: true, valid: true},
+		"streamYAML":            {output: "yaml", stream: true},
+		"streamResultOnly":      {output: "json", resultOnly: true, stream: true},
+	}
+
+	for name, tc := range tt {
+		transOutput, resultOnly, lacunasFile, stream = tc.output, tc.resultOnly, tc.lacunasFile, tc.stream
+		if err := validateTranslateOutput(nil, nil); (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid=%t, got error %v", name, tc.valid, err)
+		}
+	}
+}
@@ -2,11 +2,11 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 
 	upcue "cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
-	"cuelang.org/go/cue/ast/astutil"
-	tastutil "github.com/grafana/thema/internal/astutil"
+	"cuelang.org/go/cue/token"
 	"github.com/spf13/cobra"
 )
 
@@ -28,10 +28,31 @@ func setupLineageCommand(cmd *cobra.Command) {
 	bc := new(bumpCommand)
 	bc.setup(linCmd)
 
+	ac := new(appendCommand)
+	ac.setup(linCmd)
+
+	hc := new(importHistoryCommand)
+	hc.setup(linCmd)
+
 	gc := new(genCommand)
 	gc.setup(linCmd)
+
+	cc := new(checkCommand)
+	cc.setup(linCmd)
+
+	dc := new(diffCommand)
+	dc.setup(linCmd)
 }
 
+// toSubpath moves the lineage declared at the root of the provided file to the
+// provided CUE path. Each of the lineage's declarations is nested under the
+// path, in the style:
+//
+//	lin: thema.#Lineage
+//	lin: name: "foo"
+//	lin: seqs: [ ... ]
+//
+// The file's package clause and imports are left at the top level.
 func toSubpath(subpath string, f *ast.File) (*ast.File, error) {
 	if subpath == "" {
 		return f, nil
@@ -42,43 +63,47 @@ func toSubpath(subpath string, f *ast.File) (*ast.File, error) {
 		return nil, fmt.Errorf("invalid path provided for --cue-path: %w", p.Err())
 	}
 
-	err := astutil.Sanitize(f)
-	if err != nil {
-		return nil, fmt.Errorf("error while sanitizing generated file: %w", err)
-	}
-
-	// in := ctx.BuildFile(f)
-	// if in.Err() != nil {
-	// 	return nil, fmt.Errorf("error when building value: %w", in.Err())
-	// }
-
-	out := empt().FillPath(p, empt())
-	if out.Err() != nil {
-		return nil, fmt.Errorf("error in lineage when placed at requested --cue-path: %w", out.Err())
+	// Labels are recreated for each declaration, as AST nodes must not be
+	// shared
+	var labelfns []func() ast.Label
+	for _, sel := range p.Selectors() {
+		sel := sel
+		switch {
+		case sel.IsDefinition(), sel.IsString() && ast.IsValidIdent(sel.String()):
+			labelfns = append(labelfns, func() ast.Label { return ast.NewIdent(sel.String()) })
+		case sel.IsString():
+			// Non-identifier labels are quoted by Selector.String()
+			name, err := strconv.Unquote(sel.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid label %s in --cue-path: %w", sel, err)
+			}
+			labelfns = append(labelfns, func() ast.Label { return ast.NewString(name) })
+		default:
+			return nil, fmt.Errorf("--cue-path %q may only contain field and definition selectors, got %s", subpath, sel)
+		}
 	}
 
-	var nf *ast.File
-	switch x := tastutil.Format(out).(type) {
-	case *ast.File:
-		nf = x
-	case ast.Expr:
-		nf, err = astutil.ToFile(x)
-		if err != nil {
-			return nil, fmt.Errorf("error converting expr to file: %w", err)
+	decls := make([]ast.Decl, 0, len(f.Decls))
+	for _, d := range f.Decls {
+		var v ast.Expr
+		switch x := d.(type) {
+		case *ast.Package, *ast.ImportDecl, *ast.CommentGroup, *ast.Attribute:
+			decls = append(decls, d)
+			continue
+		case *ast.EmbedDecl:
+			v = x.Expr
+			// Keep the embedded value on the same line as its new label
+			ast.SetRelPos(v, token.Blank)
+		default:
+			v = ast.NewStruct(d)
 		}
-	}
 
-	var done bool
-	astutil.Apply(nf, func(c astutil.Cursor) bool {
-		if x, ok := c.Node().(*ast.StructLit); ok {
-			if len(x.Elts) == 0 {
-				x.Elts = f.Decls
-				done = true
-			}
+		for i := len(labelfns) - 1; i > 0; i-- {
+			v = ast.NewStruct(&ast.Field{Label: labelfns[i](), Value: v})
 		}
-		return !done
-	}, nil)
+		decls = append(decls, &ast.Field{Label: labelfns[0](), Value: v})
+	}
 
-	f.Decls = nf.Decls
+	f.Decls = decls
 	return f, nil
 }
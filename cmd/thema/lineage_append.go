@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	upcue "cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/compat"
+	"github.com/grafana/thema/encoding/cue"
+	tastutil "github.com/grafana/thema/internal/astutil"
+	"github.com/spf13/cobra"
+)
+
+var lineageAppendCmd = &cobra.Command{
+	Use:   "append <command>",
+	Short: "Append a schema from another schema language to an existing lineage",
+	Long: `Append a schema from another schema language to an existing lineage.
+
+Each subcommand supports converting a different kind of input source to a CUE
+schema, which is then appended to the lineage loaded from -l and -p.
+
+If the new schema is backwards compatible with the latest schema in the
+lineage, it is appended as the next minor version. Otherwise, it is appended as
+the first schema in a new sequence (the next major version), along with a lens
+skeleton, as with "lineage bump --major". The changes that made the schema
+backwards incompatible are reported on stderr.
+
+The file declaring the lineage is edited, and the entire file is printed to
+stdout. With --in-place, the file is instead written back to disk.
+`,
+}
+
+var lineageAppendOpenAPICmd = &cobra.Command{
+	Use:   "openapi <path>",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Append an OpenAPI v3 schema",
+	Long: `Append a schema derived from an OpenAPI v3 document.
+
+An OpenAPI document to be converted for the new schema must be given as an argument.
+`,
+}
+
+var lineageAppendJSONSchemaCmd = &cobra.Command{
+	Use:   "jsonschema <path>",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Append a JSON Schema",
+	Long: `Append a schema derived from a JSON Schema document.
+
+A JSON Schema document to be converted for the new schema must be given as an argument.
+`,
+}
+
+type appendCommand struct {
+	srcpath string
+	inplace bool
+	input   []byte
+}
+
+func (ac *appendCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageAppendCmd)
+	addLinPathVars(lineageAppendCmd)
+	lineageAppendCmd.PersistentFlags().BoolVar(&ac.inplace, "in-place", false, "Write the lineage back to its source file, rather than stdout")
+
+	lineageAppendCmd.AddCommand(lineageAppendOpenAPICmd)
+	lineageAppendOpenAPICmd.Flags().StringVar(&ac.srcpath, "src-subpath", "", "Schema path within the OpenAPI document. Default: whole document")
+	lineageAppendOpenAPICmd.Run = ac.run
+	lineageAppendOpenAPICmd.PreRunE = mergeCobraefuncs(validateLineageInput, ac.processInput)
+
+	lineageAppendCmd.AddCommand(lineageAppendJSONSchemaCmd)
+	lineageAppendJSONSchemaCmd.Flags().StringVar(&ac.srcpath, "src-subpath", "", "Schema path within the JSON Schema document (e.g. #/...) Default: whole document")
+	lineageAppendJSONSchemaCmd.Run = ac.run
+	lineageAppendJSONSchemaCmd.PreRunE = mergeCobraefuncs(validateLineageInput, ac.processInput)
+}
+
+func (ac *appendCommand) processInput(cmd *cobra.Command, args []string) error {
+	byt, err := pathOrStdin(args)
+	if err != nil {
+		return err
+	}
+
+	ac.input = byt
+	return nil
+}
+
+func (ac *appendCommand) run(cmd *cobra.Command, args []string) {
+	if err := ac.do(cmd, args); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func (ac *appendCommand) do(cmd *cobra.Command, args []string) error {
+	var sch upcue.Value
+	var err error
+	switch cmd.CalledAs() {
+	case "openapi":
+		sch, err = openAPIToCUE(ac.input, args, ac.srcpath)
+	case "jsonschema":
+		sch, err = jsonSchemaToCUE(ac.input, ac.srcpath)
+	default:
+		panic(fmt.Sprint("unrecognized command ", cmd.CalledAs()))
+	}
+	if err != nil {
+		return err
+	}
+
+	lv := thema.LatestVersion(lin)
+	lsch := thema.SchemaP(lin, lv)
+
+	path, f, seqs, err := lineageSource()
+	if err != nil {
+		return err
+	}
+
+	cerr := compat.ThemaCompatible(lsch.UnwrapCUE(), sch)
+	tgtv := thema.SV(lv[0], lv[1]+1)
+	if cerr != nil {
+		tgtv = thema.SV(lv[0]+1, 0)
+	}
+
+	// Wrap the seqs list so that only it, and not any other lineage in the
+	// file, is visible to InsertSchemaNodeAs
+	schnode := tastutil.ToExpr(tastutil.Format(sch))
+	linnode := ast.NewStruct(&ast.Field{Label: ast.NewIdent("seqs"), Value: seqs})
+	if err = cue.InsertSchemaNodeAs(linnode, schnode, tgtv); err != nil {
+		return err
+	}
+
+	errout := cmd.ErrOrStderr()
+	if cerr == nil {
+		fmt.Fprintf(errout, "appended schema as %s, as it is backwards compatible with %s\n", tgtv, lv)
+	} else {
+		lens, err := cue.LensSkeleton(lsch.UnwrapCUE(), sch, lv)
+		if err != nil {
+			return err
+		}
+		if err = addLens(seqs, lens); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(errout, "appended schema as %s, as it is backwards incompatible with %s:\n", tgtv, lv)
+		breaking := compat.Diff(lsch.UnwrapCUE(), sch).Breaking()
+		for _, c := range breaking {
+			fmt.Fprintf(errout, "  %s\n", c)
+		}
+		if len(breaking) == 0 {
+			// The field-level diff may not capture every incompatibility
+			fmt.Fprintf(errout, "  %s\n", cerr)
+		}
+		fmt.Fprintf(errout, "complete the lens skeleton in the new sequence with the mappings between %s and %s\n", lv, tgtv)
+	}
+
+	return writeLineageSource(cmd, path, f, ac.inplace)
+}
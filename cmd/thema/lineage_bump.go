@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	upcue "cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/encoding/cue"
 	tastutil "github.com/grafana/thema/internal/astutil"
@@ -12,19 +14,31 @@ import (
 )
 
 var lineageBumpCmd = &cobra.Command{
-	Use:     "bump",
+	Use:     "bump -l <lineage-fs-path> [-p <cue-path>] [--major] [--no-fill] [--in-place]",
 	PreRunE: validateLineageInput,
 	Args:    cobra.MaximumNArgs(0),
 	Short:   "Add a new schema to an existing lineage",
 	Long: `Add a new schema to an existing lineage.
 
 Generate the necessary stubs to "bump" the latest schema version in an existing lineage by adding a new schema to it.
+
+The file declaring the lineage (at the CUE path given by -p, if any) is edited,
+and the entire file is printed to stdout. With --in-place, the file is instead
+written back to disk. Comments and other declarations in the file are
+preserved, though the file is reformatted.
+
+With --major, a new sequence is added, along with a lens skeleton containing
+forward and reverse lenses. Fields that map identically between the prior and
+new schemas are copied, and lacunas are pre-filled for dropped fields and
+placeholder values. The lens must then be completed with the real semantic
+mappings between the schemas.
 `,
 }
 
 type bumpCommand struct {
 	maj      bool
 	skipfill bool
+	inplace  bool
 }
 
 func (bc *bumpCommand) setup(cmd *cobra.Command) {
@@ -32,7 +46,8 @@ func (bc *bumpCommand) setup(cmd *cobra.Command) {
 	addLinPathVars(lineageBumpCmd)
 
 	lineageBumpCmd.Flags().BoolVar(&bc.maj, "major", false, "Bump the major version (breaking change) instead of the minor version")
-	lineageBumpCmd.Flags().BoolVar(&bc.maj, "no-fill", false, "Do not pre-fill the new schema with the prior schema")
+	lineageBumpCmd.Flags().BoolVar(&bc.skipfill, "no-fill", false, "Do not pre-fill the new schema with the prior schema")
+	lineageBumpCmd.Flags().BoolVar(&bc.inplace, "in-place", false, "Write the bumped lineage back to its source file, rather than stdout")
 	lineageBumpCmd.Run = bc.run
 }
 
@@ -45,32 +60,106 @@ func (bc *bumpCommand) run(cmd *cobra.Command, args []string) {
 
 func (bc *bumpCommand) do(cmd *cobra.Command, args []string) error {
 	lv := thema.LatestVersion(lin)
+
 	lsch := thema.SchemaP(lin, lv)
-	// TODO UGH EVAL
-	schlit := tastutil.Format(lsch.UnwrapCUE().Eval())
+	nsch := lsch.UnwrapCUE()
+	var schnode ast.Expr = ast.NewStruct()
+	if bc.skipfill {
+		nsch = rt.Context().CompileString("{}")
+	} else {
+		// TODO UGH EVAL
+		schnode = tastutil.ToExpr(tastutil.Format(lsch.UnwrapCUE().Eval()))
+	}
 
-	var err error
-	var nlin ast.Node
+	path, f, seqs, err := lineageSource()
+	if err != nil {
+		return err
+	}
+
+	tgtv := thema.SV(lv[0], lv[1]+1)
 	if bc.maj {
-		nlin = lin.UnwrapCUE().Source()
-		err = cue.InsertSchemaNodeAs(nlin, tastutil.ToExpr(schlit), thema.SV(lv[0]+1, 0))
+		tgtv = thema.SV(lv[0]+1, 0)
+	}
+
+	// Wrap the seqs list so that only it, and not any other lineage in the
+	// file, is visible to InsertSchemaNodeAs
+	linnode := ast.NewStruct(&ast.Field{Label: ast.NewIdent("seqs"), Value: seqs})
+	if err = cue.InsertSchemaNodeAs(linnode, schnode, tgtv); err != nil {
+		return err
+	}
+	if bc.maj {
+		lens, err := cue.LensSkeleton(lsch.UnwrapCUE(), nsch, lv)
 		if err != nil {
 			return err
 		}
-	} else {
-		nlin, err = cue.Append(lin, lsch.UnwrapCUE())
-		if err != nil {
+		if err = addLens(seqs, lens); err != nil {
 			return err
 		}
 	}
 
-	b, err := tastutil.FmtNode(tastutil.ToExpr(nlin))
+	return writeLineageSource(cmd, path, f, bc.inplace)
+}
+
+// writeLineageSource formats the provided lineage source file, then either
+// prints it to stdout, or writes it back to path if inplace is true.
+func writeLineageSource(cmd *cobra.Command, path string, f *ast.File, inplace bool) error {
+	b, err := tastutil.FmtNode(f)
+	if err != nil {
+		return err
+	}
+
+	if !inplace {
+		fmt.Fprint(cmd.OutOrStdout(), string(b))
+		return nil
+	}
+
+	fi, err := os.Stat(path)
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(path, b, fi.Mode().Perm())
+}
 
-	// TODO write back to subpath
+// lineageSource finds and parses the file containing the seqs declaration of
+// the lineage loaded from the -l and -p flags, returning the file's path, its
+// AST, and the seqs list within that AST.
+func lineageSource() (string, *ast.File, *ast.ListLit, error) {
+	p := upcue.ParsePath(lincuepath)
+	if p.Err() != nil {
+		return "", nil, nil, fmt.Errorf("%q is not a valid CUE path expression: %s", lincuepath, p.Err())
+	}
 
-	fmt.Fprint(cmd.OutOrStdout(), string(b))
+	info, err := os.Stat(linfilepath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	paths := []string{linfilepath}
+	if info.IsDir() {
+		paths = nil
+		for _, bf := range linbinst.Files {
+			paths = append(paths, bf.Filename)
+		}
+	}
+
+	for _, path := range paths {
+		f, err := parser.ParseFile(path, nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if seqs := tastutil.FindSeqsAtPath(f, p); seqs != nil {
+			return path, f, seqs, nil
+		}
+	}
+	return "", nil, nil, fmt.Errorf("could not find declaration of lineage seqs in %s", linfilepath)
+}
+
+// addLens adds the provided lens to the last sequence in the provided seqs
+// list.
+func addLens(seqs *ast.ListLit, lens ast.Expr) error {
+	seq, ok := seqs.Elts[len(seqs.Elts)-1].(*ast.StructLit)
+	if !ok {
+		return fmt.Errorf("expected new sequence to be a struct, got %T", seqs.Elts[len(seqs.Elts)-1])
+	}
+	seq.Elts = append(seq.Elts, &ast.Field{Label: ast.NewIdent("lens"), Value: lens})
 	return nil
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"github.com/grafana/thema"
+	tastutil "github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/load"
+	"github.com/spf13/cobra"
+)
+
+const bumpSource = `package bumptest
+
+import "github.com/grafana/thema"
+
+// other is not part of the lineage
+other: "keep me" // and neither is this
+
+// lin is the lineage
+lin: thema.#Lineage
+lin: name: "bumptest"
+lin: seqs: [
+	{
+		schemas: [
+			// the first schema
+			{
+				a: string
+			},
+		]
+	},
+]
+`
+
+// bindLineageSource binds the lineage at cuepath within the provided CUE
+// source, which may import thema. The source must have a package clause.
+func bindLineageSource(t *testing.T, src []byte, cuepath string) thema.Lineage {
+	t.Helper()
+	f, err := parser.ParseFile("lin.cue", src, parser.PackageClauseOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfs := fstest.MapFS{
+		"cue.mod/module.cue": &fstest.MapFile{Data: []byte(`module: "example.com/lin"`)},
+		"lin.cue":            &fstest.MapFile{Data: src},
+	}
+	binst, err := load.InstancesWithThema(mfs, ".", load.Package(f.PackageName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := ctx.BuildInstance(binst)
+	if v.Err() != nil {
+		t.Fatal(v.Err())
+	}
+	blin, err := thema.BindLineage(v.LookupPath(cue.ParsePath(cuepath)), rt)
+	if err != nil {
+		t.Fatalf("lineage at %q does not bind: %s\n%s", cuepath, err, src)
+	}
+	return blin
+}
+
+func TestBumpInPlace(t *testing.T) {
+	t.Cleanup(func() {
+		lin, linfilepath, lincuepath = nil, "", ""
+	})
+
+	tt := map[string]struct {
+		maj  bool
+		seqs int
+	}{
+		"minor": {seqs: 1},
+		"major": {maj: true, seqs: 2},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			linfilepath, lincuepath = filepath.Join(t.TempDir(), "lin.cue"), "lin"
+			if err := os.WriteFile(linfilepath, []byte(bumpSource), 0600); err != nil {
+				t.Fatal(err)
+			}
+			lin = bindLineageSource(t, []byte(bumpSource), lincuepath)
+
+			var stdout bytes.Buffer
+			cmd := &cobra.Command{}
+			cmd.SetOut(&stdout)
+			bc := &bumpCommand{maj: tc.maj, inplace: true}
+			if err := bc.do(cmd, nil); err != nil {
+				t.Fatal(err)
+			}
+			if stdout.Len() != 0 {
+				t.Fatalf("expected nothing on stdout with --in-place, got:\n%s", stdout.String())
+			}
+
+			b, err := os.ReadFile(linfilepath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fi, err := os.Stat(linfilepath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != 0600 {
+				t.Errorf("expected file mode to be retained, got %s", fi.Mode().Perm())
+			}
+
+			for _, s := range []string{
+				"package bumptest\n",
+				`import "github.com/grafana/thema"`,
+				"// other is not part of the lineage\nother: \"keep me\" // and neither is this\n",
+				"// lin is the lineage\nlin: thema.#Lineage\n",
+				`lin: name: "bumptest"`,
+				"// the first schema\n",
+			} {
+				if !strings.Contains(string(b), s) {
+					t.Errorf("expected bumped file to retain %q, got:\n%s", s, b)
+				}
+			}
+
+			f, err := parser.ParseFile(linfilepath, b, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seqs := tastutil.FindSeqsAtPath(f, cue.ParsePath(lincuepath))
+			if seqs == nil || len(seqs.Elts) != tc.seqs {
+				t.Fatalf("expected %d sequences after bump, got:\n%s", tc.seqs, b)
+			}
+			if !tc.maj {
+				// A new major version identical to its predecessor is invalid
+				// until the author changes it, so only minor bumps can bind
+				blin := bindLineageSource(t, b, lincuepath)
+				if lv := thema.LatestVersion(blin); lv != thema.SV(0, 1) {
+					t.Fatalf("expected latest version 0.1 after bump, got %s", lv)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/thema"
+	"github.com/spf13/cobra"
+)
+
+var checkLineageCmd = &cobra.Command{
+	Use:   "check",
+	Args:  cobra.MaximumNArgs(0),
+	Short: "Report all invariant violations in a lineage",
+	Long: `Report all invariant violations in a lineage.
+
+Check a lineage against all of Thema's invariants, reporting every violation
+found along with the file:line:col location of the offending CUE. Unlike other
+commands, which fail at the first violation, this command is intended for
+surfacing problems in lineages under development, e.g. in editors or CI.
+
+Output is available as plain text (default), JSON, or SARIF. Exits non-zero if
+any violations are found.
+`,
+}
+
+type checkCommand struct {
+	format string
+}
+
+func (cc *checkCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(checkLineageCmd)
+	addLinPathVars(checkLineageCmd)
+
+	checkLineageCmd.Flags().StringVarP(&cc.format, "format", "f", "text", "output format. \"text\", \"json\", or \"sarif\".")
+	checkLineageCmd.Run = cc.run
+}
+
+func (cc *checkCommand) run(cmd *cobra.Command, args []string) {
+	n, err := cc.do(cmd, args)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", err)
+		os.Exit(1)
+	}
+	if n > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkResult is the JSON representation of a single invariant violation.
+type checkResult struct {
+	File    string   `json:"file,omitempty"`
+	Line    int      `json:"line,omitempty"`
+	Column  int      `json:"column,omitempty"`
+	Schemas []string `json:"schemas,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Message string   `json:"message"`
+}
+
+func (cc *checkCommand) do(cmd *cobra.Command, args []string) (int, error) {
+	v, err := lineageValueFromPaths(rt, linfilepath, lincuepath)
+	if err != nil {
+		return 0, err
+	}
+
+	violations := thema.CheckLineage(v, rt)
+	results := make([]checkResult, 0, len(violations))
+	for _, vi := range violations {
+		res := checkResult{
+			Path:    strings.Join(vi.Path, "."),
+			Message: vi.Message,
+		}
+		if vi.Pos.IsValid() {
+			res.File = relPath(vi.Pos.Filename())
+			res.Line = vi.Pos.Line()
+			res.Column = vi.Pos.Column()
+		}
+		for _, sv := range vi.Schemas {
+			res.Schemas = append(res.Schemas, sv.String())
+		}
+		results = append(results, res)
+	}
+
+	out := cmd.OutOrStdout()
+	switch cc.format {
+	case "text":
+		for _, res := range results {
+			if res.File != "" {
+				fmt.Fprintf(out, "%s:%d:%d: ", res.File, res.Line, res.Column)
+			}
+			if len(res.Schemas) == 2 {
+				fmt.Fprintf(out, "%s -> %s: ", res.Schemas[0], res.Schemas[1])
+			}
+			if res.Path != "" {
+				fmt.Fprintf(out, "%s: ", res.Path)
+			}
+			fmt.Fprintln(out, res.Message)
+		}
+	case "json":
+		err = printJSON(out, results)
+	case "sarif":
+		err = printJSON(out, toSARIF(results))
+	default:
+		return 0, fmt.Errorf(`unrecognized output format %q - must choose "text", "json" or "sarif"`, cc.format)
+	}
+
+	return len(results), err
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// relPath makes the provided path relative to the working directory, if
+// possible, so that locations are portable across machines (e.g. in CI).
+func relPath(p string) string {
+	wd, err := os.Getwd()
+	if err != nil || !filepath.IsAbs(p) {
+		return p
+	}
+	if rel, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+const sarifRuleID = "thema/lineage-invariant"
+
+// toSARIF converts check results into a SARIF 2.1.0 log.
+//
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+func toSARIF(results []checkResult) map[string]interface{} {
+	sresults := make([]map[string]interface{}, 0, len(results))
+	for _, res := range results {
+		msg := res.Message
+		if res.Path != "" {
+			msg = res.Path + ": " + msg
+		}
+		if len(res.Schemas) == 2 {
+			msg = fmt.Sprintf("%s -> %s: %s", res.Schemas[0], res.Schemas[1], msg)
+		}
+
+		sres := map[string]interface{}{
+			"ruleId":  sarifRuleID,
+			"level":   "error",
+			"message": map[string]interface{}{"text": msg},
+		}
+		if res.File != "" {
+			sres["locations"] = []map[string]interface{}{{
+				"physicalLocation": map[string]interface{}{
+					"artifactLocation": map[string]interface{}{"uri": res.File},
+					"region": map[string]interface{}{
+						"startLine":   res.Line,
+						"startColumn": res.Column,
+					},
+				},
+			}}
+		}
+		sresults = append(sresults, sres)
+	}
+
+	return map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":           "thema",
+					"informationUri": "https://github.com/grafana/thema",
+					"rules": []map[string]interface{}{{
+						"id":               sarifRuleID,
+						"shortDescription": map[string]interface{}{"text": "Lineage violates a Thema invariant"},
+					}},
+				},
+			},
+			"results": sresults,
+		}},
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/compat"
+	"github.com/spf13/cobra"
+)
+
+var diffLineageCmd = &cobra.Command{
+	Use:     "diff <from> <to>",
+	PreRunE: validateLineageInput,
+	Args:    cobra.ExactArgs(2),
+	Short:   "Report field-level changes between two schemas in a lineage",
+	Long: `Report field-level changes between two schemas in a lineage.
+
+Compare the schemas in a lineage with the two provided versions, reporting
+each added or removed field, change in field optionality, narrowed or widened
+constraint, and changed default. Each change is classified as either
+compatible or breaking.
+
+The report is available as plain text (default) or JSON.
+`,
+}
+
+type diffCommand struct {
+	format string
+}
+
+func (dc *diffCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(diffLineageCmd)
+	addLinPathVars(diffLineageCmd)
+
+	diffLineageCmd.Flags().StringVarP(&dc.format, "format", "f", "text", "output format. \"text\" or \"json\".")
+	diffLineageCmd.Run = dc.run
+}
+
+func (dc *diffCommand) run(cmd *cobra.Command, args []string) {
+	if err := dc.do(cmd, args); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func (dc *diffCommand) do(cmd *cobra.Command, args []string) error {
+	var schs [2]thema.Schema
+	for i, arg := range args {
+		v, err := thema.ParseSyntacticVersion(arg)
+		if err != nil {
+			return err
+		}
+		if schs[i], err = lin.Schema(v); err != nil {
+			return err
+		}
+	}
+
+	r := compat.Diff(schs[0].UnwrapCUE(), schs[1].UnwrapCUE())
+
+	out := cmd.OutOrStdout()
+	switch dc.format {
+	case "text":
+		status := "compatible"
+		if r.IsBreaking() {
+			status = "BREAKING"
+		}
+		fmt.Fprintf(out, "%s -> %s: %s (%d changes, %d breaking)\n", schs[0].Version(), schs[1].Version(), status, len(r.Changes), len(r.Breaking()))
+		for _, c := range r.Changes {
+			tag := "compatible"
+			if c.Breaking {
+				tag = "breaking"
+			}
+			fmt.Fprintf(out, "  %-12s %s\n", "["+tag+"]", c)
+		}
+		return nil
+	case "json":
+		return printJSON(out, struct {
+			From     string `json:"from"`
+			To       string `json:"to"`
+			Breaking bool   `json:"breaking"`
+			*compat.Report
+		}{
+			From:     schs[0].Version().String(),
+			To:       schs[1].Version().String(),
+			Breaking: r.IsBreaking(),
+			Report:   r,
+		})
+	default:
+		return fmt.Errorf(`unrecognized output format %q - must choose "text" or "json"`, dc.format)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	upcue "cuelang.org/go/cue"
+	"github.com/grafana/thema/compat"
+	"github.com/grafana/thema/encoding/cue"
+	"github.com/spf13/cobra"
+)
+
+var lineageImportHistoryCmd = &cobra.Command{
+	Use:   "import-history -n <name> <path>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Create a new lineage from a versioned history of schema documents",
+	Long: `Create a new lineage from a versioned history of schema documents.
+
+The paths to one or more JSON Schema or OpenAPI v3 documents must be given as
+arguments, ordered from oldest to newest. Each document is converted to a CUE
+schema, and the schemas become the schemas of the new lineage in that order.
+OpenAPI documents are recognized by their top-level "openapi" field. With
+--openapi-subpath or --jsonschema-subpath, the schema at that path within each
+document of the corresponding kind is used.
+
+The version of each schema is determined by compatibility with its predecessor.
+Backwards compatible schemas are appended as the next minor version.
+Incompatible schemas start a new sequence, along with a lens skeleton that
+copies all fields whose type is unchanged between the schemas, as with
+"lineage bump --major". The version assigned to each document, and the changes
+that made a new sequence necessary, are reported on stderr.
+
+As with "lineage init", the lineage is generated at the root of a new file
+printed to stdout, unless --cue-path or --into are given.
+`,
+}
+
+type importHistoryCommand struct {
+	initCommand
+
+	oapipath string
+	jspath   string
+}
+
+func (hc *importHistoryCommand) setup(cmd *cobra.Command) {
+	cmd.AddCommand(lineageImportHistoryCmd)
+	lineageImportHistoryCmd.Flags().StringVarP(&hc.name, "name", "n", "", "String for the #Lineage.name field")
+	lineageImportHistoryCmd.Flags().StringVar(&hc.pkgname, "package-name", "", "Name for generated package. If omitted, --name value is used")
+	lineageImportHistoryCmd.Flags().BoolVar(&hc.nopkg, "no-package", false, "Generate lineage without a package directive")
+	lineageImportHistoryCmd.Flags().StringVarP(&hc.cuepath, "cue-path", "p", "", "CUE expression for subpath at which lineage should be generated")
+	lineageImportHistoryCmd.Flags().StringVar(&hc.into, "into", "", "Path to an existing .cue file to merge the generated lineage into. Requires --cue-path")
+	lineageImportHistoryCmd.Flags().StringVar(&hc.oapipath, "openapi-subpath", "", "Schema path within each OpenAPI document. Default: whole document")
+	lineageImportHistoryCmd.Flags().StringVar(&hc.jspath, "jsonschema-subpath", "", "Schema path within each JSON Schema document (e.g. #/...) Default: whole document")
+	lineageImportHistoryCmd.MarkFlagRequired("name")
+
+	lineageImportHistoryCmd.Run = hc.run
+	lineageImportHistoryCmd.PreRunE = mergeCobraefuncs(hc.processPackageArgs, hc.processSubpathArgs)
+}
+
+func (hc *importHistoryCommand) run(cmd *cobra.Command, args []string) {
+	if err := hc.do(cmd, args); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func (hc *importHistoryCommand) do(cmd *cobra.Command, args []string) error {
+	schs := make([]upcue.Value, len(args))
+	for i, path := range args {
+		sch, err := hc.convert(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		schs[i] = sch
+	}
+
+	linf, versions, err := cue.NewLineageFromHistory(schs, hc.name, hc.pkgname)
+	if err != nil {
+		return err
+	}
+
+	errout := cmd.ErrOrStderr()
+	for i, v := range versions {
+		fmt.Fprintf(errout, "%s: %s\n", args[i], v)
+		if i == 0 || v[1] != 0 {
+			continue
+		}
+		for _, c := range compat.Diff(schs[i-1], schs[i]).Breaking() {
+			fmt.Fprintf(errout, "  %s\n", c)
+		}
+	}
+
+	return hc.emit(cmd, linf)
+}
+
+// convert converts the JSON Schema or OpenAPI document at path to a CUE schema.
+func (hc *importHistoryCommand) convert(path string) (upcue.Value, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return upcue.Value{}, err
+	}
+
+	f, err := inputToFile(byt, []string{path})
+	if err != nil {
+		return upcue.Value{}, err
+	}
+	if ctx.BuildFile(f).LookupPath(upcue.ParsePath("openapi")).Exists() {
+		return openAPIToCUE(byt, []string{path}, hc.oapipath)
+	}
+	return jsonSchemaToCUE(byt, hc.jspath)
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	upcue "cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
@@ -11,13 +13,16 @@ import (
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/load"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 	"cuelang.org/go/encoding/json"
 	"cuelang.org/go/encoding/jsonschema"
 	"cuelang.org/go/encoding/openapi"
 	"cuelang.org/go/encoding/yaml"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/encoding/cue"
+	"github.com/grafana/thema/encoding/tgo"
 	tastutil "github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/vmux"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +32,8 @@ type initCommand struct {
 	pkgname string
 	nopkg   bool
 	srcpath string
+	into    string
+	maxenum int
 	input   []byte
 
 	err error
@@ -38,23 +45,32 @@ func (ic *initCommand) setup(cmd *cobra.Command) {
 	initLineageCmd.PersistentFlags().StringVar(&ic.pkgname, "package-name", "", "Name for generated package. If omitted, --name value is used")
 	initLineageCmd.PersistentFlags().BoolVar(&ic.nopkg, "no-package", false, "Generate lineage without a package directive")
 
-	// TODO uncomment these and fix broken toSubpath logic to support generating at a subpath
-	// initLineageCmd.MarkFlagRequired("name")
-	// initLineageCmd.PersistentFlags().StringVarP(&ic.cuepath, "cue-path", "p", "", "CUE expression for subpath at which lineage should be generated")
+	initLineageCmd.MarkPersistentFlagRequired("name")
+	initLineageCmd.PersistentFlags().StringVarP(&ic.cuepath, "cue-path", "p", "", "CUE expression for subpath at which lineage should be generated")
+	initLineageCmd.PersistentFlags().StringVar(&ic.into, "into", "", "Path to an existing .cue file to merge the generated lineage into. Requires --cue-path")
 
 	initLineageCmd.AddCommand(initLineageEmptyCmd)
 	initLineageEmptyCmd.Run = ic.run
-	initLineageEmptyCmd.PreRunE = ic.processPackageArgs
+	initLineageEmptyCmd.PreRunE = mergeCobraefuncs(ic.processPackageArgs, ic.processSubpathArgs)
 
 	initLineageCmd.AddCommand(initLineageOpenAPICmd)
 	initLineageOpenAPICmd.Flags().StringVar(&ic.srcpath, "src-subpath", "", "Schema path within the OpenAPI document. Default: whole document")
 	initLineageOpenAPICmd.Run = ic.run
-	initLineageOpenAPICmd.PreRunE = ic.processInput
+	initLineageOpenAPICmd.PreRunE = mergeCobraefuncs(ic.processInput, ic.processSubpathArgs)
 
 	initLineageCmd.AddCommand(initLineageJSONSchemaCmd)
 	initLineageJSONSchemaCmd.Flags().StringVar(&ic.srcpath, "src-subpath", "", "Schema path within the JSON Schema document (e.g. #/...) Default: whole document")
 	initLineageJSONSchemaCmd.Run = ic.run
-	initLineageJSONSchemaCmd.PreRunE = ic.processInput
+	initLineageJSONSchemaCmd.PreRunE = mergeCobraefuncs(ic.processInput, ic.processSubpathArgs)
+
+	initLineageCmd.AddCommand(initLineageGoTypeCmd)
+	initLineageGoTypeCmd.Run = ic.run
+	initLineageGoTypeCmd.PreRunE = mergeCobraefuncs(ic.processPackageArgs, ic.processSubpathArgs)
+
+	initLineageCmd.AddCommand(initLineageInferCmd)
+	initLineageInferCmd.Flags().IntVar(&ic.maxenum, "max-enum", 5, "Maximum number of distinct string values for a field to be inferred as an enum. 0 disables enum inference")
+	initLineageInferCmd.Run = ic.run
+	initLineageInferCmd.PreRunE = mergeCobraefuncs(ic.processPackageArgs, ic.processSubpathArgs)
 }
 
 var initLineageCmd = &cobra.Command{
@@ -63,6 +79,12 @@ var initLineageCmd = &cobra.Command{
 	Long: `Create a new lineage.
 
 Each subcommand supports initializing the lineage from a different kind of input source.
+
+By default, the lineage is generated at the root of a new file. With --cue-path,
+it is instead generated at the provided CUE path, e.g. "lin". With --into, the
+lineage is merged into an existing .cue file, which is modified in place. The
+existing file's package clause is retained, and an import of thema is added if
+necessary. No lineage may already exist at --cue-path within the file.
 `,
 }
 
@@ -102,6 +124,48 @@ The generated lineage is printed to stdout.
 `,
 }
 
+var initLineageGoTypeCmd = &cobra.Command{
+	Use:   "gotype <package> <type>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Initialize with a schema derived from a Go struct type",
+	Long: `Initialize the lineage with one schema, derived from a Go struct type.
+
+The Go package containing the type must be given as the first argument, either
+as an import path or as a relative directory path (e.g. ./pkg/foo). The name of
+the struct type within that package must be given as the second argument.
+
+The schema follows the behavior of encoding/json: json struct tags determine
+field names, fields tagged omitempty or having pointer types are optional, and
+the fields of embedded structs are promoted. The resulting schema is assignable
+to the Go type.
+
+The generated lineage is printed to stdout.
+`,
+}
+
+var initLineageInferCmd = &cobra.Command{
+	Use:   "infer <path>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Initialize with a schema inferred from sample data",
+	Long: `Initialize the lineage with one schema, inferred from a corpus of sample data.
+
+The paths to one or more JSON or YAML files, each containing an object, must be
+given as arguments. All of the samples validate against the inferred schema,
+once any null fields are omitted.
+
+The fields of all samples are merged. Fields that are not present in every
+sample (or in every object at the same position within a sample, such as the
+elements of a list) are optional. As Thema schemas express optionality with ?
+rather than null, fields observed as null are also optional, and do not accept
+null. Fields whose values differ in kind across
+samples are inferred as a disjunction of those kinds. Fields with a small set of
+repeated string values, at least two and no more than --max-enum, are inferred
+as an enum of those values.
+
+The generated lineage is printed to stdout.
+`,
+}
+
 func (ic *initCommand) run(cmd *cobra.Command, args []string) {
 	switch cmd.CalledAs() {
 	case "empty":
@@ -110,6 +174,10 @@ func (ic *initCommand) run(cmd *cobra.Command, args []string) {
 		ic.runJSONSchema(cmd, args)
 	case "openapi":
 		ic.runOpenAPI(cmd, args)
+	case "gotype":
+		ic.runGoType(cmd, args)
+	case "infer":
+		ic.runInfer(cmd, args)
 	default:
 		panic(fmt.Sprint("unrecognized command ", cmd.CalledAs()))
 	}
@@ -140,6 +208,19 @@ func (ic *initCommand) processPackageArgs(cmd *cobra.Command, args []string) err
 	return nil
 }
 
+func (ic *initCommand) processSubpathArgs(cmd *cobra.Command, args []string) error {
+	if ic.into == "" {
+		return nil
+	}
+	if ic.cuepath == "" {
+		return fmt.Errorf("--into requires a --cue-path at which to place the lineage")
+	}
+	if _, err := os.Stat(ic.into); err != nil {
+		return err
+	}
+	return nil
+}
+
 // process both openapi and json schema, abstract over stdin
 func (ic *initCommand) processInput(cmd *cobra.Command, args []string) error {
 	byt, err := pathOrStdin(args)
@@ -151,16 +232,6 @@ func (ic *initCommand) processInput(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func empt() upcue.Value {
-	str := `
-{
-	// TODO (delete me - first schema goes here!)
-}
-`
-	expr, _ := parser.ParseExpr("empty", str, parser.ParseComments)
-	return ctx.BuildExpr(expr)
-}
-
 func (ic *initCommand) runEmpty(cmd *cobra.Command, args []string) {
 	str := `
 {
@@ -182,36 +253,40 @@ func (ic *initCommand) runEmpty(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	linf, err = toSubpath(ic.cuepath, linf)
+	ic.err = ic.emit(cmd, linf)
+}
+
+func (ic *initCommand) runJSONSchema(cmd *cobra.Command, args []string) {
+	sch, err := jsonSchemaToCUE(ic.input, ic.srcpath)
 	if err != nil {
 		ic.err = err
 		return
 	}
 
-	b, err := tastutil.FmtNode(linf)
+	linf, err := cue.NewLineage(sch, ic.name, ic.pkgname)
 	if err != nil {
 		ic.err = err
 		return
 	}
 
-	fmt.Fprint(cmd.OutOrStdout(), string(b))
+	ic.err = ic.emit(cmd, linf)
 }
 
-func (ic *initCommand) runJSONSchema(cmd *cobra.Command, args []string) {
-	v := ctx.CompileBytes(ic.input)
+// jsonSchemaToCUE converts the JSON Schema document in input, or the schema at
+// srcpath within it, to a CUE schema.
+func jsonSchemaToCUE(input []byte, srcpath string) (upcue.Value, error) {
+	v := ctx.CompileBytes(input)
 	if v.Err() != nil {
-		ic.err = v.Err()
-		return
+		return upcue.Value{}, v.Err()
 	}
 
 	jcfg := &jsonschema.Config{
-		Root: ic.srcpath,
+		Root: srcpath,
 	}
 
 	f, err := jsonschema.Extract(v, jcfg)
 	if err != nil {
-		ic.err = err
-		return
+		return upcue.Value{}, err
 	}
 
 	sch := ctx.BuildFile(f)
@@ -227,25 +302,7 @@ func (ic *initCommand) runJSONSchema(cmd *cobra.Command, args []string) {
 		return is
 	}, nil)
 
-	linf, err := cue.NewLineage(sch.Eval(), ic.name, ic.pkgname)
-	if err != nil {
-		ic.err = err
-		return
-	}
-
-	linf, err = toSubpath(ic.cuepath, linf)
-	if err != nil {
-		ic.err = err
-		return
-	}
-
-	b, err := tastutil.FmtNode(linf)
-	if err != nil {
-		ic.err = err
-		return
-	}
-
-	fmt.Fprint(cmd.OutOrStdout(), string(b))
+	return sch.Eval(), nil
 }
 
 // expects something else to have already gotten the input from either a file
@@ -288,22 +345,38 @@ func inputToFile(input []byte, args []string) (*ast.File, error) {
 }
 
 func (ic *initCommand) runOpenAPI(cmd *cobra.Command, args []string) {
-	f, err := inputToFile(ic.input, args)
+	sch, err := openAPIToCUE(ic.input, args, ic.srcpath)
 	if err != nil {
 		ic.err = err
 		return
 	}
 
-	rt := (*upcue.Runtime)(ctx)
-	inst, err := rt.CompileFile(f)
+	linf, err := cue.NewLineage(sch, ic.name, ic.pkgname)
 	if err != nil {
 		ic.err = err
 		return
 	}
+
+	ic.err = ic.emit(cmd, linf)
+}
+
+// openAPIToCUE converts the OpenAPI document in input, or the schema at srcpath
+// within it, to a CUE schema. args are the command's arguments, from which
+// the encoding of the input is determined.
+func openAPIToCUE(input []byte, args []string, srcpath string) (upcue.Value, error) {
+	f, err := inputToFile(input, args)
+	if err != nil {
+		return upcue.Value{}, err
+	}
+
+	rt := (*upcue.Runtime)(ctx)
+	inst, err := rt.CompileFile(f)
+	if err != nil {
+		return upcue.Value{}, err
+	}
 	fo, err := openapi.Extract(inst, &openapi.Config{})
 	if err != nil {
-		ic.err = err
-		return
+		return upcue.Value{}, err
 	}
 	// Remove info field
 	var done bool
@@ -325,38 +398,180 @@ func (ic *initCommand) runOpenAPI(cmd *cobra.Command, args []string) {
 	}, nil)
 
 	sch := ctx.BuildFile(fo)
-	if ic.srcpath != "" {
-		p := upcue.ParsePath(ic.srcpath)
+	if srcpath != "" {
+		p := upcue.ParsePath(srcpath)
 		if p.Err() != nil {
-			ic.err = fmt.Errorf("value for --src-subpath is not a valid cue path expression: %w", p.Err())
-			return
+			return upcue.Value{}, fmt.Errorf("value for --src-subpath is not a valid cue path expression: %w", p.Err())
 		}
 		// Eval will do dereferencing for us as needed, but may have other unintended
 		// side effects.
 		sch = sch.LookupPath(p).Eval()
 		if !sch.Exists() {
-			ic.err = fmt.Errorf("path %q does not exist in converted schema", p.String())
-			return
+			return upcue.Value{}, fmt.Errorf("path %q does not exist in converted schema", p.String())
 		}
 	}
+	return sch, nil
+}
 
-	linf, err := cue.NewLineage(sch, ic.name, ic.pkgname)
+func (ic *initCommand) runGoType(cmd *cobra.Command, args []string) {
+	expr, err := tgo.SchemaFromPackage(".", args[0], args[1])
 	if err != nil {
 		ic.err = err
 		return
 	}
 
-	linf, err = toSubpath(ic.cuepath, linf)
+	ic.err = ic.emitSchemaNode(cmd, expr)
+}
+
+func (ic *initCommand) runInfer(cmd *cobra.Command, args []string) {
+	samples := make([]upcue.Value, 0, len(args))
+	for _, path := range args {
+		var dec vmux.Decoder
+		switch filepath.Ext(path) {
+		case ".json":
+			dec = vmux.NewJSONEndec(path)
+		case ".yaml", ".yml":
+			dec = vmux.NewYAMLEndec(path)
+		default:
+			ic.err = fmt.Errorf("%s: unsupported file extension, must be .json, .yaml, or .yml", path)
+			return
+		}
+
+		byt, err := os.ReadFile(path)
+		if err != nil {
+			ic.err = err
+			return
+		}
+		v, err := dec.Decode(ctx, byt)
+		if err != nil {
+			ic.err = fmt.Errorf("%s: %w", path, err)
+			return
+		}
+		samples = append(samples, v)
+	}
+
+	expr, err := cue.InferSchema(samples, ic.maxenum)
 	if err != nil {
 		ic.err = err
 		return
 	}
 
-	b, err := tastutil.FmtNode(linf)
+	ic.err = ic.emitSchemaNode(cmd, expr)
+}
+
+// emitSchemaNode generates a new lineage with the provided node as its only
+// schema, then emits it.
+func (ic *initCommand) emitSchemaNode(cmd *cobra.Command, expr ast.Expr) error {
+	linf, err := cue.NewLineage(ctx.BuildExpr(expr), ic.name, ic.pkgname)
 	if err != nil {
-		ic.err = err
-		return
+		return err
+	}
+
+	// Re-insert the generated node, as NewLineage does not preserve optional
+	// fields
+	if err = cue.InsertSchemaNodeAs(linf, expr, thema.SV(0, 0)); err != nil {
+		return err
+	}
+
+	return ic.emit(cmd, linf)
+}
+
+// emit moves the generated lineage to the --cue-path, then either prints it, or
+// merges it into the --into file.
+func (ic *initCommand) emit(cmd *cobra.Command, linf *ast.File) error {
+	linf, err := toSubpath(ic.cuepath, linf)
+	if err != nil {
+		return err
+	}
+	if ic.into != "" {
+		return ic.mergeInto(linf)
 	}
 
+	b, err := tastutil.FmtNode(linf)
+	if err != nil {
+		return err
+	}
 	fmt.Fprint(cmd.OutOrStdout(), string(b))
+	return nil
+}
+
+const themaImportPath = "github.com/grafana/thema"
+
+// mergeInto appends the declarations of the generated lineage file to the
+// --into file, writing it back in place.
+func (ic *initCommand) mergeInto(linf *ast.File) error {
+	f, err := parser.ParseFile(ic.into, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	if tastutil.FindSeqsAtPath(f, upcue.ParsePath(ic.cuepath)) != nil {
+		return fmt.Errorf("%s already contains a lineage at path %q", ic.into, ic.cuepath)
+	}
+	if err = addThemaImport(f); err != nil {
+		return fmt.Errorf("%s: %w", ic.into, err)
+	}
+
+	rel := token.NewSection // Separate the lineage from existing declarations
+	for _, d := range linf.Decls {
+		switch d.(type) {
+		case *ast.Package, *ast.ImportDecl:
+		default:
+			ast.SetRelPos(d, rel)
+			rel = token.Newline
+			f.Decls = append(f.Decls, d)
+		}
+	}
+
+	b, err := tastutil.FmtNode(f)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(ic.into)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ic.into, b, fi.Mode().Perm())
+}
+
+// addThemaImport adds an import of thema to the file, if it does not already
+// have one. Generated lineages refer to the package as "thema", so an existing
+// import with any other name is an error.
+func addThemaImport(f *ast.File) error {
+	last := -1
+	var lastimp *ast.ImportDecl
+	for i, d := range f.Decls {
+		switch x := d.(type) {
+		case *ast.Package:
+			last = i
+		case *ast.ImportDecl:
+			last, lastimp = i, x
+			for _, spec := range x.Specs {
+				if path, _ := strconv.Unquote(spec.Path.Value); path != themaImportPath {
+					continue
+				}
+				if spec.Name != nil && spec.Name.Name != "thema" {
+					return fmt.Errorf("thema is imported as %q, but generated lineages require it to be imported as \"thema\"", spec.Name.Name)
+				}
+				return nil
+			}
+		}
+	}
+
+	spec := ast.NewImport(nil, themaImportPath)
+	if lastimp != nil {
+		// Join the existing import declaration
+		if !lastimp.Lparen.IsValid() {
+			lastimp.Lparen, lastimp.Rparen = token.Blank.Pos(), token.Newline.Pos()
+			for _, s := range lastimp.Specs {
+				ast.SetRelPos(s, token.Newline)
+			}
+		}
+		ast.SetRelPos(spec, token.Newline)
+		lastimp.Specs = append(lastimp.Specs, spec)
+		return nil
+	}
+
+	imp := &ast.ImportDecl{Specs: []*ast.ImportSpec{spec}}
+	f.Decls = append(f.Decls[:last+1], append([]ast.Decl{imp}, f.Decls[last+1:]...)...)
+	return nil
 }
@@ -9,9 +9,11 @@ import (
 
 	"cuelang.org/go/pkg/encoding/yaml"
 	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/crd"
 	"github.com/grafana/thema/encoding/jsonschema"
 	"github.com/grafana/thema/encoding/openapi"
 	"github.com/grafana/thema/encoding/tgo"
+	"github.com/grafana/thema/encoding/typescript"
 	"github.com/spf13/cobra"
 )
 
@@ -31,6 +33,19 @@ type genCommand struct {
 	pkgname string
 	// path for embedding
 	epath string
+	// crd generation config
+	crdcfg crd.Config
+	// served versions for crd generation
+	crdserved []string
+	// storage version for crd generation
+	crdstorage string
+	// name of the root TypeScript interface
+	tsroot string
+	// don't generate the TypeScript defaults constant
+	tsnodefaults bool
+	// output formats for openapi, jsonschema and crd generation. Each command
+	// needs its own var, as flag registration writes the default to it.
+	oapifmt, jschfmt, crdfmt string
 }
 
 func (gc *genCommand) setup(cmd *cobra.Command) {
@@ -41,12 +56,12 @@ func (gc *genCommand) setup(cmd *cobra.Command) {
 
 	genLineageCmd.AddCommand(genOapiLineageCmd)
 	genOapiLineageCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to generate. Defaults to latest")
-	genOapiLineageCmd.Flags().StringVarP(&encoding, "format", "f", "yaml", "output format. \"json\" or \"yaml\".")
+	genOapiLineageCmd.Flags().StringVarP(&gc.oapifmt, "format", "f", "yaml", "output format. \"json\" or \"yaml\".")
 	genOapiLineageCmd.Run = gc.run
 
 	genLineageCmd.AddCommand(genJschLineageCmd)
 	genJschLineageCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to generate. Defaults to latest")
-	genJschLineageCmd.Flags().StringVarP(&encoding, "format", "f", "json", "output format. \"json\" or \"yaml\".")
+	genJschLineageCmd.Flags().StringVarP(&gc.jschfmt, "format", "f", "json", "output format. \"json\" or \"yaml\".")
 	genJschLineageCmd.Run = gc.run
 
 	genLineageCmd.AddCommand(genGoTypesLineageCmd)
@@ -64,9 +79,27 @@ func (gc *genCommand) setup(cmd *cobra.Command) {
 	genGoBindingsLineageCmd.Flags().BoolVar(&gc.noembed, "no-embed", false, "Do not generate an embed.FS, allowing it to be handwritten")
 	genGoBindingsLineageCmd.Run = gc.run
 
-	// TODO
-	// genLineageCmd.AddCommand(genTSTypesLineageCmd)
-	// genTSTypesLineageCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to generate. Defaults to latest")
+	genLineageCmd.AddCommand(genCRDLineageCmd)
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.Group, "group", "", "API group of the custom resource, e.g. stable.example.com")
+	genCRDLineageCmd.MarkFlagRequired("group")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.Scope, "scope", "Namespaced", "Scope of the custom resource. \"Namespaced\" or \"Cluster\"")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.Kind, "kind", "", "Kind of the custom resource. Defaults to lineage name")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.ListKind, "list-kind", "", "Kind of lists of the custom resource. Defaults to <kind>List")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.Plural, "plural", "", "Plural name of the custom resource. Defaults to lowercase lineage name + \"s\"")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.Singular, "singular", "", "Singular name of the custom resource. Defaults to lowercase kind")
+	genCRDLineageCmd.Flags().StringSliceVar(&gc.crdcfg.ShortNames, "short-names", nil, "Short names for the custom resource")
+	genCRDLineageCmd.Flags().StringSliceVar(&gc.crdcfg.Categories, "categories", nil, "Categories to which the custom resource belongs")
+	genCRDLineageCmd.Flags().StringSliceVar(&gc.crdserved, "served", nil, "Schema versions to serve. Defaults to all")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdstorage, "storage", "", "Schema version to use for storage. Defaults to latest")
+	genCRDLineageCmd.Flags().StringVar(&gc.crdcfg.WebhookURL, "webhook-url", "", "URL of a Thema conversion webhook. If set, the CRD uses the Webhook conversion strategy")
+	genCRDLineageCmd.Flags().StringVarP(&gc.crdfmt, "format", "f", "yaml", "output format. \"json\" or \"yaml\".")
+	genCRDLineageCmd.Run = gc.run
+
+	genLineageCmd.AddCommand(genTSTypesLineageCmd)
+	genTSTypesLineageCmd.Flags().StringVarP((*string)(&verstr), "version", "v", "", "schema syntactic version to generate. Defaults to latest")
+	genTSTypesLineageCmd.Flags().StringVar(&gc.tsroot, "root-name", "", "Name of the generated root interface. Defaults to capitalized lineage name")
+	genTSTypesLineageCmd.Flags().BoolVar(&gc.tsnodefaults, "no-defaults", false, "Do not generate a constant containing schema default values")
+	genTSTypesLineageCmd.Run = gc.run
 }
 
 func (gc *genCommand) run(cmd *cobra.Command, args []string) {
@@ -96,6 +129,8 @@ func (gc *genCommand) run(cmd *cobra.Command, args []string) {
 		err = gc.runGoBindings(cmd, args)
 	case "tstypes":
 		err = gc.runTSTypes(cmd, args)
+	case "crd":
+		err = gc.runCRD(cmd, args)
 	default:
 		panic(fmt.Sprint("unrecognized command ", cmd.CalledAs()))
 	}
@@ -140,7 +175,7 @@ func (gc *genCommand) runOpenAPI(cmd *cobra.Command, args []string) error {
 	}
 
 	var str string
-	switch encoding {
+	switch gc.oapifmt {
 	case "json":
 		var b []byte
 		b, err = rt.Context().BuildFile(f).MarshalJSON()
@@ -152,7 +187,7 @@ func (gc *genCommand) runOpenAPI(cmd *cobra.Command, args []string) error {
 	case "yaml", "yml":
 		str, err = yaml.Marshal(rt.Context().BuildFile(f))
 	default:
-		fmt.Fprintf(cmd.ErrOrStderr(), `unrecognized output format %q - must choose "yaml" or "json"`, encoding)
+		fmt.Fprintf(cmd.ErrOrStderr(), `unrecognized output format %q - must choose "yaml" or "json"`, gc.oapifmt)
 	}
 	if err != nil {
 		return err
@@ -177,7 +212,7 @@ func (gc *genCommand) runJSONSchema(cmd *cobra.Command, args []string) error {
 	}
 
 	var str string
-	switch encoding {
+	switch gc.jschfmt {
 	case "json":
 		var b []byte
 		b, err = rt.Context().BuildFile(f).MarshalJSON()
@@ -189,7 +224,7 @@ func (gc *genCommand) runJSONSchema(cmd *cobra.Command, args []string) error {
 	case "yaml", "yml":
 		str, err = yaml.Marshal(rt.Context().BuildFile(f))
 	default:
-		fmt.Fprintf(cmd.ErrOrStderr(), `unrecognized output format %q - must choose "yaml" or "json"`, encoding)
+		fmt.Fprintf(cmd.ErrOrStderr(), `unrecognized output format %q - must choose "yaml" or "json"`, gc.jschfmt)
 	}
 	if err != nil {
 		return err
@@ -267,19 +302,95 @@ func (gc *genCommand) runGoBindings(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var genCRDLineageCmd = &cobra.Command{
+	Use:   "crd",
+	Short: "Generate a Kubernetes CustomResourceDefinition from a lineage",
+	Long: `Generate a Kubernetes CustomResourceDefinition from a lineage.
+
+Generate a complete apiextensions.k8s.io/v1 CustomResourceDefinition containing
+one version per schema in the lineage. The openAPIV3Schema for each version is
+generated from the corresponding schema, as with "thema lineage gen openapi".
+`,
+}
+
+func (gc *genCommand) runCRD(cmd *cobra.Command, args []string) error {
+	cfg := gc.crdcfg
+	for _, s := range gc.crdserved {
+		v, err := thema.ParseSyntacticVersion(s)
+		if err != nil {
+			return err
+		}
+		cfg.Served = append(cfg.Served, v)
+	}
+	if gc.crdstorage != "" {
+		v, err := thema.ParseSyntacticVersion(gc.crdstorage)
+		if err != nil {
+			return err
+		}
+		cfg.Storage = &v
+	}
+
+	f, err := crd.GenerateCRD(gc.lin, &cfg)
+	if err != nil {
+		return err
+	}
+
+	var str string
+	switch gc.crdfmt {
+	case "json":
+		var b []byte
+		b, err = rt.Context().BuildFile(f).MarshalJSON()
+		if b != nil {
+			nb := new(bytes.Buffer)
+			err = json.Indent(nb, b, "", "  ")
+			str = nb.String()
+		}
+	case "yaml", "yml":
+		str, err = yaml.Marshal(rt.Context().BuildFile(f))
+	default:
+		return fmt.Errorf(`unrecognized output format %q - must choose "yaml" or "json"`, gc.crdfmt)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), str)
+	return nil
+}
+
 var genTSTypesLineageCmd = &cobra.Command{
 	Use:   "tstypes",
 	Short: "Generate TypeScript types from a lineage",
 	Long: `Generate TypeScript types from a lineage.
 
-Generate a JSON Schema document representing a single schema in a lineage.
+Generate TypeScript interfaces that correspond to a single schema in a lineage.
+
+The schema is represented as an exported interface, accompanied by an exported
+constant containing the schema's default values, if it has any.
 `,
 }
 
 func (gc *genCommand) runTSTypes(cmd *cobra.Command, args []string) error {
-	panic("TODO")
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, tsheader, gc.epath)
+	b, err := typescript.GenerateTypes(gc.sch, &typescript.TypeConfig{
+		RootName:   gc.tsroot,
+		NoDefaults: gc.tsnodefaults,
+	})
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+
+	fmt.Fprint(cmd.OutOrStdout(), buf.String())
+	return nil
 }
 
+var tsheader = `// This file is autogenerated. DO NOT EDIT.
+//
+// Generated by "thema lineage gen" from lineage defined in %s
+
+`
+
 var goheader = `// This file is autogenerated. DO NOT EDIT.
 //
 // Generated by "thema lineage gen" from lineage defined in %s
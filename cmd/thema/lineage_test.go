@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/cue"
+	tastutil "github.com/grafana/thema/internal/astutil"
+)
+
+// newTestLineageFile generates a new lineage file, as lineage init does.
+func newTestLineageFile(t *testing.T) *ast.File {
+	t.Helper()
+	linf, err := cue.NewLineage(ctx.CompileString(`{ a: string }`), "sub", "sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return linf
+}
+
+func TestToSubpath(t *testing.T) {
+	for _, p := range []string{"", "lin", `a.b."c-d"`, "#Lin"} {
+		linf, err := toSubpath(p, newTestLineageFile(t))
+		if err != nil {
+			t.Fatalf("%s: %s", p, err)
+		}
+		b, err := tastutil.FmtNode(linf)
+		if err != nil {
+			t.Fatalf("%s: %s", p, err)
+		}
+
+		blin := bindLineageSource(t, b, p)
+		if blin.Name() != "sub" || thema.LatestVersion(blin) != thema.SV(0, 0) {
+			t.Fatalf("%s: unexpected lineage %q with latest version %s", p, blin.Name(), thema.LatestVersion(blin))
+		}
+	}
+
+	for _, p := range []string{"a[0]", "a.[string]: b", "_hidden"} {
+		if _, err := toSubpath(p, newTestLineageFile(t)); err == nil {
+			t.Errorf("%s: expected error for invalid subpath", p)
+		}
+	}
+}
+
+func TestMergeInto(t *testing.T) {
+	tt := map[string]struct {
+		into, cuepath string
+		// substrings expected in the merged file, or of the error if err is set
+		contains []string
+		err      bool
+	}{
+		"noImports": {
+			into:    "package existing\n\n// foo is retained\nfoo: 1\n",
+			cuepath: "lin",
+			contains: []string{
+				"package existing\n\nimport \"github.com/grafana/thema\"\n\n// foo is retained\nfoo: 1\n",
+			},
+		},
+		"otherImport": {
+			into:    "package existing\n\nimport \"strings\"\n\nfoo: strings.ToUpper(\"x\")\n",
+			cuepath: "lin",
+			contains: []string{
+				"import (\n\t\"strings\"\n\t\"github.com/grafana/thema\"\n)\n",
+				"foo: strings.ToUpper(\"x\")\n",
+			},
+		},
+		"themaImported": {
+			into:    "package existing\n\nimport \"github.com/grafana/thema\"\n\nfoo: 1\n",
+			cuepath: "lin",
+			contains: []string{
+				"package existing\n\nimport \"github.com/grafana/thema\"\n\nfoo: 1\n",
+			},
+		},
+		"themaImportedInGroup": {
+			into:    "package existing\n\nimport (\n\t\"strings\"\n\t\"github.com/grafana/thema\"\n)\n\nfoo: strings.ToUpper(\"x\")\n",
+			cuepath: `a.b."c-d"`,
+			contains: []string{
+				"import (\n\t\"strings\"\n\t\"github.com/grafana/thema\"\n)\n",
+				`a: b: "c-d": name: "sub"`,
+			},
+		},
+		"themaImportedWithOtherName": {
+			into:     "package existing\n\nimport th \"github.com/grafana/thema\"\n\nfoo: th.#Lineage\n",
+			cuepath:  "lin",
+			contains: []string{`thema is imported as "th"`},
+			err:      true,
+		},
+		"lineageExists": {
+			into:     "package existing\n\nlin: seqs: []\n",
+			cuepath:  "lin",
+			contains: []string{`already contains a lineage at path "lin"`},
+			err:      true,
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			into := filepath.Join(t.TempDir(), "existing.cue")
+			if err := os.WriteFile(into, []byte(tc.into), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			linf, err := toSubpath(tc.cuepath, newTestLineageFile(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ic := &initCommand{into: into, cuepath: tc.cuepath}
+			err = ic.mergeInto(linf)
+			b, rerr := os.ReadFile(into)
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got merged file:\n%s", b)
+				}
+				for _, s := range tc.contains {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("expected error to contain %q, got %q", s, err)
+					}
+				}
+				if string(b) != tc.into {
+					t.Errorf("file was modified despite error:\n%s", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tc.contains {
+				if !strings.Contains(string(b), s) {
+					t.Errorf("expected merged file to contain %q, got:\n%s", s, b)
+				}
+			}
+			if n := strings.Count(string(b), `"github.com/grafana/thema"`); n != 1 {
+				t.Errorf("expected thema to be imported once, got %d imports:\n%s", n, b)
+			}
+			if blin := bindLineageSource(t, b, tc.cuepath); blin.Name() != "sub" {
+				t.Errorf("unexpected lineage name %q", blin.Name())
+			}
+		})
+	}
+}
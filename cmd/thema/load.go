@@ -83,26 +83,56 @@ func buildInsts(rt *thema.Runtime, binsts []*build.Instance, ppath ppathf, cuepa
 	}
 }
 
-func loadone(rt *thema.Runtime, binst *build.Instance, pkgpath, cuepath string) (thema.Lineage, error) {
+// lineageValueFromPaths takes a filepath and an optional CUE path expression
+// and loads the result up, without binding it to a Lineage.
+func lineageValueFromPaths(rt *thema.Runtime, filepath, cuepath string) (cue.Value, error) {
+	if filepath == "" {
+		panic("empty filepath")
+	}
+
+	if _, err := os.Stat(filepath); err != nil {
+		return cue.Value{}, err
+	}
+
+	binsts := load.Instances([]string{filepath}, &load.Config{})
+	switch len(binsts) {
+	case 0:
+		return cue.Value{}, fmt.Errorf("no loadable CUE data found")
+	case 1:
+		return loadValue(rt, binsts[0], filepath, cuepath)
+	default:
+		return cue.Value{}, fmt.Errorf("multiple CUE packages found at %s, specify a single file", filepath)
+	}
+}
+
+func loadValue(rt *thema.Runtime, binst *build.Instance, pkgpath, cuepath string) (cue.Value, error) {
 	if binst.Err != nil {
-		return nil, binst.Err
+		return cue.Value{}, binst.Err
 	}
 
 	v := rt.UnwrapCUE().Context().BuildInstance(binst)
 	if !v.Exists() {
-		return nil, fmt.Errorf("empty instance at %s", pkgpath)
+		return cue.Value{}, fmt.Errorf("empty instance at %s", pkgpath)
 	}
 
 	if cuepath != "" {
 		p := cue.ParsePath(cuepath)
 		if p.Err() != nil {
-			return nil, fmt.Errorf("%q is not a valid CUE path expression: %s", cuepath, p.Err())
+			return cue.Value{}, fmt.Errorf("%q is not a valid CUE path expression: %s", cuepath, p.Err())
 		}
 		v = v.LookupPath(p)
 		if !v.Exists() {
-			return nil, fmt.Errorf("no value at path %q in instance %q", cuepath, pkgpath)
+			return cue.Value{}, fmt.Errorf("no value at path %q in instance %q", cuepath, pkgpath)
 		}
 	}
+	return v, nil
+}
+
+func loadone(rt *thema.Runtime, binst *build.Instance, pkgpath, cuepath string) (thema.Lineage, error) {
+	v, err := loadValue(rt, binst, pkgpath, cuepath)
+	if err != nil {
+		return nil, err
+	}
 	// FIXME so hacky to write back to a global this way - only OK because buildInsts guarantees only one can escape
 	linbinst = binst
 
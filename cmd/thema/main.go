@@ -54,6 +54,7 @@ var sch thema.Schema
 func main() {
 	setupDataCommand(rootCmd)
 	setupLineageCommand(rootCmd)
+	setupSrvCommand(rootCmd)
 
 	// Stop cobra from being so "helpful"
 	for _, cmd := range allCmds {
@@ -64,8 +65,6 @@ func main() {
 		}
 	}
 
-	// srv commands
-	// TODO
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -87,18 +86,28 @@ var allCmds = []*cobra.Command{
 	translateCmd,
 	validateCmd,
 	validateAnyCmd,
+	migrateCmd,
 	linCmd,
 	initLineageCmd,
 	initLineageEmptyCmd,
 	initLineageOpenAPICmd,
 	initLineageJSONSchemaCmd,
+	initLineageGoTypeCmd,
+	initLineageInferCmd,
 	lineageBumpCmd,
+	lineageAppendCmd,
+	lineageAppendOpenAPICmd,
+	lineageAppendJSONSchemaCmd,
+	lineageImportHistoryCmd,
+	checkLineageCmd,
+	diffLineageCmd,
 	genLineageCmd,
 	genTSTypesLineageCmd,
 	genGoBindingsLineageCmd,
 	genGoTypesLineageCmd,
 	genOapiLineageCmd,
 	genJschLineageCmd,
+	genCRDLineageCmd,
 }
 
 var rootCmd = &cobra.Command{
@@ -111,7 +120,7 @@ This program offers several kinds of behavior for working with Thema:
 * Validating and inspecting of written lineages.
 * Given a valid lineage, provides basic Thema operations (validate, translate,
   [de]hydrate) on some input data.
-* Run an HTTP server that exposes basic Thema operations to the network. (TODO)
+* Run an HTTP server that exposes basic Thema operations to the network.
 * Provides scaffolding for writing lineages, lenses, and schema. (TODO)
 `,
 }
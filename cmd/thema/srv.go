@@ -1,25 +1,353 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/grafana/thema/vmux"
+	"github.com/spf13/cobra"
+)
+
+type srvCommand struct {
+	addr     string
+	linpaths []string
+}
 
 func setupSrvCommand(cmd *cobra.Command) {
 	cmd.AddCommand(srvCmd)
 
+	sc := new(srvCommand)
+	srvCmd.AddCommand(httpCmd)
+	httpCmd.Flags().StringVar(&sc.addr, "addr", ":8080", "address on which to listen for HTTP requests")
+	httpCmd.Flags().StringArrayVarP(&sc.linpaths, "lineage", "l", nil, "path to .cue file or directory containing a lineage, optionally followed by :<cue-path>. May be repeated")
+	httpCmd.MarkFlagRequired("lineage")
+	httpCmd.RunE = sc.run
 }
 
 var srvCmd = &cobra.Command{
 	Use:   "srv <command>",
 	Short: "Run a server that offers Thema operations over the network",
 	Long: `Run a server that offers Thema operations over the network.
-
-	TODO not yet implemented
 `,
 }
 
 var httpCmd = &cobra.Command{
-	Use:    "http",
-	Hidden: true,
-	Short:  "Start an HTTP(S) server",
-	Long: `Start an HTTP(S) server.
+	Use:   "http -l <lineage-fs-path>[:<cue-path>] [-l ...] [--addr <addr>]",
+	Short: "Start an HTTP server",
+	Long: `Start an HTTP server.
+
+The server exposes the same operations as the "thema data" subcommands, for
+each of the lineages passed via -l. Lineages are loaded and validated once,
+at startup, and are addressed by their name:
+
+  GET  /lineages
+  POST /lineages/<name>/validate
+  POST /lineages/<name>/validate-any
+  POST /lineages/<name>/translate
+  POST /lineages/<name>/hydrate
+  POST /lineages/<name>/dehydrate
+
+Request bodies are JSON objects with the input instance in the "data" field,
+and the schema version (e.g. "1.0") in the "version" field. For translate,
+the target version is given in the "to" field instead.
+
+All responses are JSON. Failures are reported with a non-2xx status code and
+an object containing an "error" field.
 `,
+	Args: cobra.MaximumNArgs(0),
+}
+
+func (sc *srvCommand) run(cmd *cobra.Command, args []string) error {
+	srv := &themaServer{
+		lins: make(map[string]thema.Lineage),
+	}
+	for _, lp := range sc.linpaths {
+		fpath, cpath := splitLinPath(lp)
+		slin, err := lineageFromPaths(rt, fpath, cpath)
+		if err != nil {
+			return fmt.Errorf("error loading lineage from %q: %w", lp, err)
+		}
+		if _, has := srv.lins[slin.Name()]; has {
+			return fmt.Errorf("multiple lineages provided with name %q", slin.Name())
+		}
+		srv.lins[slin.Name()] = slin
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "serving %d lineage(s) on %s\n", len(srv.lins), sc.addr)
+	return http.ListenAndServe(sc.addr, srv)
+}
+
+// splitLinPath splits a <fs-path>[:<cue-path>] argument into its component
+// parts.
+func splitLinPath(s string) (string, string) {
+	i := strings.LastIndex(s, ":")
+	if i == -1 || strings.ContainsRune(s[i:], os.PathSeparator) {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// themaServer is an http.Handler that exposes Thema data operations over a set
+// of lineages.
+type themaServer struct {
+	lins map[string]thema.Lineage
+
+	// CUE evaluation is not safe for concurrent use, so requests are
+	// serialized.
+	mut sync.Mutex
+}
+
+// srvRequest is the body of all POST requests.
+type srvRequest struct {
+	// Version is the schema version to operate against. Optional for all
+	// operations except validate.
+	Version string `json:"version,omitempty"`
+	// To is the target version for translation.
+	To string `json:"to,omitempty"`
+	// Data is the input object instance.
+	Data json.RawMessage `json:"data"`
+}
+
+type srvVersionResponse struct {
+	Version string `json:"version"`
+}
+
+type srvDataResponse struct {
+	Version string    `json:"version"`
+	Result  cue.Value `json:"result"`
+}
+
+type srvErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type srvLineageInfo struct {
+	Name     string   `json:"name"`
+	Versions []string `json:"versions"`
+}
+
+func (s *themaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "lineages" || len(parts) > 3 {
+		writeJSON(w, http.StatusNotFound, srvErrorResponse{Error: "not found"})
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, srvErrorResponse{Error: "method not allowed"})
+			return
+		}
+		s.list(w)
+		return
+	}
+
+	slin, has := s.lins[parts[1]]
+	if !has {
+		writeJSON(w, http.StatusNotFound, srvErrorResponse{Error: fmt.Sprintf("no lineage with name %q", parts[1])})
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, srvErrorResponse{Error: "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, lineageInfo(slin))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, srvErrorResponse{Error: "method not allowed"})
+		return
+	}
+
+	var req srvRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, srvErrorResponse{Error: fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+	if len(req.Data) == 0 {
+		writeJSON(w, http.StatusBadRequest, srvErrorResponse{Error: "request body must contain input in the data field"})
+		return
+	}
+
+	datv, err := vmux.NewJSONEndec("data").Decode(rt.Context(), req.Data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, srvErrorResponse{Error: fmt.Sprintf("could not decode data: %s", err)})
+		return
+	}
+
+	var status int
+	var resp interface{}
+	switch parts[2] {
+	case "validate":
+		status, resp = s.validate(slin, req, datv)
+	case "validate-any":
+		status, resp = s.validateAny(slin, req, datv)
+	case "translate":
+		status, resp = s.translate(slin, req, datv)
+	case "hydrate", "dehydrate":
+		status, resp = s.hydrate(slin, req, datv, parts[2] == "hydrate")
+	default:
+		status, resp = http.StatusNotFound, srvErrorResponse{Error: fmt.Sprintf("unknown operation %q", parts[2])}
+	}
+	writeJSON(w, status, resp)
+}
+
+func (s *themaServer) list(w http.ResponseWriter) {
+	infos := make([]srvLineageInfo, 0, len(s.lins))
+	for _, slin := range s.lins {
+		infos = append(infos, lineageInfo(slin))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func lineageInfo(slin thema.Lineage) srvLineageInfo {
+	info := srvLineageInfo{
+		Name: slin.Name(),
+	}
+	for isch := thema.SchemaP(slin, thema.SV(0, 0)); isch != nil; isch = isch.Successor() {
+		info.Versions = append(info.Versions, isch.Version().String())
+	}
+	return info
+}
+
+func (s *themaServer) validate(slin thema.Lineage, req srvRequest, datv cue.Value) (int, interface{}) {
+	if req.Version == "" {
+		return http.StatusBadRequest, srvErrorResponse{Error: "must provide a schema version in the version field"}
+	}
+	ssch, status, err := schemaFromReq(slin, req.Version)
+	if err != nil {
+		return status, srvErrorResponse{Error: err.Error()}
+	}
+
+	if _, err = ssch.Validate(datv); err != nil {
+		return http.StatusUnprocessableEntity, srvErrorResponse{Error: err.Error()}
+	}
+	return http.StatusOK, srvVersionResponse{Version: ssch.Version().String()}
+}
+
+func (s *themaServer) validateAny(slin thema.Lineage, req srvRequest, datv cue.Value) (int, interface{}) {
+	inst, status, err := validateReqData(slin, req, datv)
+	if err != nil {
+		return status, srvErrorResponse{Error: err.Error()}
+	}
+	return http.StatusOK, srvVersionResponse{Version: inst.Schema().Version().String()}
+}
+
+func (s *themaServer) translate(slin thema.Lineage, req srvRequest, datv cue.Value) (int, interface{}) {
+	if req.To == "" {
+		return http.StatusBadRequest, srvErrorResponse{Error: "must provide a target schema version in the to field"}
+	}
+	to, err := thema.ParseSyntacticVersion(req.To)
+	if err != nil {
+		return http.StatusBadRequest, srvErrorResponse{Error: err.Error()}
+	}
+
+	inst, status, err := validateReqData(slin, req, datv)
+	if err != nil {
+		return status, srvErrorResponse{Error: err.Error()}
+	}
+
+	tinst, lac, err := inst.TranslateE(to)
+	if err != nil {
+		switch {
+		case errors.Is(err, terrors.ErrVersionNotExist):
+			return http.StatusNotFound, srvErrorResponse{Error: err.Error()}
+		case errors.Is(err, terrors.ErrTranslationFailed):
+			return http.StatusInternalServerError, srvErrorResponse{Error: err.Error()}
+		default:
+			return http.StatusBadRequest, srvErrorResponse{Error: err.Error()}
+		}
+	}
+	if err = validateTranslationResult(tinst, lac); err != nil {
+		return http.StatusInternalServerError, srvErrorResponse{Error: err.Error()}
+	}
+
+	return http.StatusOK, translationResult{
+		From:    inst.Schema().Version().String(),
+		To:      tinst.Schema().Version().String(),
+		Result:  tinst.UnwrapCUE(),
+		Lacunas: lac,
+	}
+}
+
+func (s *themaServer) hydrate(slin thema.Lineage, req srvRequest, datv cue.Value, hydrate bool) (int, interface{}) {
+	inst, status, err := validateReqData(slin, req, datv)
+	if err != nil {
+		return status, srvErrorResponse{Error: err.Error()}
+	}
+
+	if hydrate {
+		inst = inst.Hydrate()
+	} else {
+		inst = inst.Dehydrate()
+	}
+	return http.StatusOK, srvDataResponse{
+		Version: inst.Schema().Version().String(),
+		Result:  inst.UnwrapCUE(),
+	}
+}
+
+func schemaFromReq(slin thema.Lineage, vstr string) (thema.Schema, int, error) {
+	v, err := thema.ParseSyntacticVersion(vstr)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	ssch, err := slin.Schema(v)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return ssch, 0, nil
+}
+
+// validateReqData validates the input data against the requested schema
+// version, if one was given, or otherwise searches the lineage for a schema
+// against which the data is valid. Data that is invalid against an explicitly
+// requested version is an error, rather than a cue to search the lineage, so
+// that operations never run against a schema other than the one requested.
+func validateReqData(slin thema.Lineage, req srvRequest, datv cue.Value) (*thema.Instance, int, error) {
+	if req.Version != "" {
+		ssch, status, err := schemaFromReq(slin, req.Version)
+		if err != nil {
+			return nil, status, err
+		}
+		inst, err := ssch.Validate(datv)
+		if err != nil {
+			return nil, http.StatusUnprocessableEntity, err
+		}
+		return inst, 0, nil
+	}
+
+	if inst := slin.ValidateAny(datv); inst != nil {
+		return inst, 0, nil
+	}
+	return nil, http.StatusUnprocessableEntity, errors.New("input data is not valid for any schema in lineage")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	byt, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		byt, _ = json.Marshal(srvErrorResponse{Error: fmt.Sprintf("error marshaling response to JSON: %s", err)})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(byt) //nolint:errcheck
 }
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+)
+
+const srvLineage = `
+name: "srvtest"
+seqs: [
+	{
+		schemas: [
+			{ a: string },
+		]
+	},
+	{
+		schemas: [
+			// The forward lens fails to translate a: "fail"
+			{ b: string & !="fail", c: int | *5 },
+		]
+		lens: forward: {
+			from: seqs[0].schemas[0]
+			to: seqs[1].schemas[0]
+			rel: b: from.a
+			lacunas: []
+			translated: to & rel
+		}
+		lens: reverse: {
+			from: seqs[1].schemas[0]
+			to: seqs[0].schemas[0]
+			rel: a: from.b
+			lacunas: []
+			translated: to & rel
+		}
+	},
+]
+`
+
+func TestServeHTTP(t *testing.T) {
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(srvLineage))
+	slin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &themaServer{
+		lins: map[string]thema.Lineage{slin.Name(): slin},
+	}
+
+	table := []struct {
+		name, method, path, body string
+		status                   int
+		// expected response, compared after normalizing both to compact JSON
+		resp string
+	}{
+		{
+			name:   "list",
+			method: http.MethodGet, path: "/lineages",
+			status: http.StatusOK,
+			resp:   `[{"name":"srvtest","versions":["0.0","1.0"]}]`,
+		},
+		{
+			name:   "listBadMethod",
+			method: http.MethodPost, path: "/lineages",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "info",
+			method: http.MethodGet, path: "/lineages/srvtest",
+			status: http.StatusOK,
+			resp:   `{"name":"srvtest","versions":["0.0","1.0"]}`,
+		},
+		{
+			name:   "infoBadMethod",
+			method: http.MethodPost, path: "/lineages/srvtest",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "unknownPath",
+			method: http.MethodGet, path: "/foo",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "unknownLineage",
+			method: http.MethodGet, path: "/lineages/nope",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "unknownOperation",
+			method: http.MethodPost, path: "/lineages/srvtest/nope", body: `{"data": {"a": "x"}}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "operationBadMethod",
+			method: http.MethodGet, path: "/lineages/srvtest/validate",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "badBody",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"data":`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missingData",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"version": "0.0"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "validate",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"version": "0.0", "data": {"a": "x"}}`,
+			status: http.StatusOK,
+			resp:   `{"version":"0.0"}`,
+		},
+		{
+			name:   "validateMissingVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"data": {"a": "x"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "validateMalformedVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"version": "zero", "data": {"a": "x"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "validateUnknownVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"version": "2.0", "data": {"a": "x"}}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "validateInvalid",
+			method: http.MethodPost, path: "/lineages/srvtest/validate", body: `{"version": "1.0", "data": {"a": "x"}}`,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "validateAny",
+			method: http.MethodPost, path: "/lineages/srvtest/validate-any", body: `{"data": {"b": "x"}}`,
+			status: http.StatusOK,
+			resp:   `{"version":"1.0"}`,
+		},
+		{
+			name:   "validateAnyInvalid",
+			method: http.MethodPost, path: "/lineages/srvtest/validate-any", body: `{"data": {"d": "x"}}`,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "translate",
+			method: http.MethodPost, path: "/lineages/srvtest/translate", body: `{"to": "1.0", "data": {"a": "x"}}`,
+			status: http.StatusOK,
+			resp:   `{"from":"0.0","to":"1.0","result":{"b":"x","c":5},"lacunas":[]}`,
+		},
+		{
+			name:   "translateUnknownVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/translate", body: `{"to": "2.0", "data": {"a": "x"}}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "translateFailed",
+			method: http.MethodPost, path: "/lineages/srvtest/translate", body: `{"to": "1.0", "data": {"a": "fail"}}`,
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "translateMissingTo",
+			method: http.MethodPost, path: "/lineages/srvtest/translate", body: `{"data": {"a": "x"}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "translateInvalidForVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/translate", body: `{"version": "1.0", "to": "0.0", "data": {"a": "x"}}`,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "hydrate",
+			method: http.MethodPost, path: "/lineages/srvtest/hydrate", body: `{"version": "1.0", "data": {"b": "x"}}`,
+			status: http.StatusOK,
+			resp:   `{"version":"1.0","result":{"b":"x","c":5}}`,
+		},
+		{
+			name:   "hydrateInvalidForVersion",
+			method: http.MethodPost, path: "/lineages/srvtest/hydrate", body: `{"version": "1.0", "data": {"a": "x"}}`,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "dehydrate",
+			method: http.MethodPost, path: "/lineages/srvtest/dehydrate", body: `{"data": {"b": "x", "c": 5}}`,
+			status: http.StatusOK,
+			resp:   `{"version":"1.0","result":{"b":"x"}}`,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("expected JSON response, got content type %q", ct)
+			}
+
+			var got interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not valid JSON: %s", err)
+			}
+			if tt.status >= 400 {
+				if m, ok := got.(map[string]interface{}); !ok || m["error"] == "" {
+					t.Fatalf("expected error response, got %s", rec.Body)
+				}
+				return
+			}
+
+			var want interface{}
+			if err := json.Unmarshal([]byte(tt.resp), &want); err != nil {
+				t.Fatal(err)
+			}
+			wantb, _ := json.Marshal(want)
+			gotb, _ := json.Marshal(got)
+			if string(wantb) != string(gotb) {
+				t.Fatalf("unexpected response:\n\twant: %s\n\tgot:  %s", wantb, gotb)
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+// Package compat provides tools for analyzing the backwards compatibility of
+// changes between schemas.
+//
+// Thema's invariants require that schemas within a sequence are backwards
+// compatible with their predecessor, and that the first schema in a sequence
+// is backwards incompatible with the last schema in the prior sequence.
+// Where those invariants only determine whether a change is compatible, this
+// package reports which individual field changes make it so.
+//
+// This package operates on plain cue.Values, rather than thema.Schema, so that
+// it may be used by Thema itself. Use [thema.Schema.UnwrapCUE] to obtain the
+// cue.Value for a schema.
+package compat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/token"
+)
+
+// ChangeKind identifies the class of a single field-level change between
+// schemas.
+type ChangeKind string
+
+const (
+	// FieldAdded indicates a field that is present in the next schema, but not
+	// the prior schema. Breaking if the field is required and has no default.
+	FieldAdded ChangeKind = "FieldAdded"
+
+	// FieldRemoved indicates a field that is present in the prior schema, but
+	// not the next schema. Always breaking, as Thema schemas are closed.
+	FieldRemoved ChangeKind = "FieldRemoved"
+
+	// MadeOptional indicates a field that was required in the prior schema,
+	// but is optional in the next schema. Never breaking.
+	MadeOptional ChangeKind = "MadeOptional"
+
+	// MadeRequired indicates a field that was optional in the prior schema,
+	// but is required in the next schema. Always breaking.
+	MadeRequired ChangeKind = "MadeRequired"
+
+	// ConstraintWidened indicates a field that accepts all the values it
+	// accepted in the prior schema, and more. Never breaking.
+	ConstraintWidened ChangeKind = "ConstraintWidened"
+
+	// ConstraintNarrowed indicates a field that accepts only a subset of the
+	// values it accepted in the prior schema. Always breaking.
+	ConstraintNarrowed ChangeKind = "ConstraintNarrowed"
+
+	// ConstraintChanged indicates a field whose accepted values have changed
+	// such that neither the prior nor the next constraint accepts all the
+	// values of the other. Always breaking.
+	ConstraintChanged ChangeKind = "ConstraintChanged"
+
+	// DefaultAdded indicates a field that has a default in the next schema, but
+	// not the prior schema. Never breaking.
+	DefaultAdded ChangeKind = "DefaultAdded"
+
+	// DefaultRemoved indicates a field that has a default in the prior schema,
+	// but not the next schema. Always breaking.
+	DefaultRemoved ChangeKind = "DefaultRemoved"
+
+	// DefaultChanged indicates a field with a different default in the next
+	// schema than in the prior schema. Always breaking, as it changes the
+	// meaning of existing data which relied on the default.
+	DefaultChanged ChangeKind = "DefaultChanged"
+)
+
+// Change describes a single field-level change between two schemas.
+type Change struct {
+	// Kind is the class of the change.
+	Kind ChangeKind `json:"kind"`
+
+	// Path is the path to the changed field, relative to the schema root.
+	// Path elements of "[]" refer to the elements of a list.
+	Path []string `json:"path"`
+
+	// Prior is the CUE representation of the relevant value (constraint or
+	// default) in the prior schema. Empty if there is no such value.
+	Prior string `json:"prior,omitempty"`
+
+	// Next is the CUE representation of the relevant value (constraint or
+	// default) in the next schema. Empty if there is no such value.
+	Next string `json:"next,omitempty"`
+
+	// Breaking indicates whether the change is backwards incompatible.
+	Breaking bool `json:"breaking"`
+
+	// Pos is the source position of the changed field, in the next schema if
+	// the field exists there, or else in the prior schema.
+	Pos token.Pos `json:"-"`
+}
+
+// String returns a human-readable description of the change, prefixed with
+// the path to the changed field.
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", strings.Join(c.Path, "."), c.Description())
+}
+
+// Description returns a human-readable description of the change.
+func (c Change) Description() string {
+	var msg string
+	switch c.Kind {
+	case FieldAdded:
+		msg = fmt.Sprintf("field added with type `%s`", c.Next)
+	case FieldRemoved:
+		msg = fmt.Sprintf("field with type `%s` removed", c.Prior)
+	case MadeOptional:
+		msg = "field made optional"
+	case MadeRequired:
+		msg = "field made required"
+	case ConstraintWidened:
+		msg = fmt.Sprintf("constraint widened from `%s` to `%s`", c.Prior, c.Next)
+	case ConstraintNarrowed:
+		msg = fmt.Sprintf("constraint narrowed from `%s` to `%s`", c.Prior, c.Next)
+	case ConstraintChanged:
+		msg = fmt.Sprintf("constraint changed from `%s` to `%s`", c.Prior, c.Next)
+	case DefaultAdded:
+		msg = fmt.Sprintf("default `%s` added", c.Next)
+	case DefaultRemoved:
+		msg = fmt.Sprintf("default `%s` removed", c.Prior)
+	case DefaultChanged:
+		msg = fmt.Sprintf("default changed from `%s` to `%s`", c.Prior, c.Next)
+	default:
+		msg = string(c.Kind)
+	}
+	return msg
+}
+
+// Report is the set of field-level changes between two schemas.
+type Report struct {
+	// Changes contains all field-level changes, in field order of the next
+	// schema, followed by any removed fields.
+	Changes []Change `json:"changes"`
+}
+
+// IsBreaking indicates whether any of the changes in the report are
+// backwards incompatible.
+func (r *Report) IsBreaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Breaking returns only the backwards incompatible changes in the report.
+func (r *Report) Breaking() []Change {
+	var changes []Change
+	for _, c := range r.Changes {
+		if c.Breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// Diff produces a field-level report of all changes from the prior schema to
+// the next schema, classifying each as compatible or breaking.
+func Diff(prior, next cue.Value) *Report {
+	d := &differ{}
+	d.diff(prior, next, nil)
+	return &Report{
+		Changes: d.changes,
+	}
+}
+
+// ThemaCompatible checks that the next schema is backwards compatible with the
+// prior schema, according to Thema's rules. A nil return indicates the next
+// schema is backwards compatible.
+//
+// Compatibility is primarily determined by CUE subsumption: all data that is
+// valid against the prior schema must also be valid against the next schema.
+// Thema extends this in two ways:
+//
+//   - Required fields with a default may be added, as data that lacks such a
+//     field is made valid by the default.
+//   - The default of a field may not change, as that changes the meaning of
+//     existing data that relied on the prior default. See [CheckDefaults].
+func ThemaCompatible(prior, next cue.Value) error {
+	r := Diff(prior, next)
+	if err := next.Subsume(prior, subsumeOpts...); err != nil {
+		// Subsumption does not allow the addition of required fields, even
+		// with defaults. Retry with such fields added to the prior schema,
+		// as though the data had been filled with their defaults.
+		relaxed, added := withAddedDefaults(prior, next, r)
+		if !added {
+			return err
+		}
+		if rerr := next.Subsume(relaxed, subsumeOpts...); rerr != nil {
+			return rerr
+		}
+	}
+
+	// Subsumption is blind to changes in defaults, so check them separately
+	return r.defaultChanges()
+}
+
+var subsumeOpts = []cue.Option{cue.Raw(), cue.Schema(), cue.Definitions(true), cue.All(), cue.Final()}
+
+// withAddedDefaults returns the prior schema with all fields that were
+// compatibly added in the next schema - optional, or required with a default -
+// filled in, according to the report of changes between them. The boolean
+// result indicates whether there were any such fields.
+func withAddedDefaults(prior, next cue.Value, r *Report) (cue.Value, bool) {
+	var added bool
+	for _, c := range r.Changes {
+		if c.Kind != FieldAdded || c.Breaking {
+			continue
+		}
+		p := changePath(c.Path)
+		prior = prior.FillPath(p, next.LookupPath(p))
+		added = true
+	}
+	return prior, added
+}
+
+// changePath converts the path of a Change to a cue.Path. Path elements of
+// "[]" refer to all elements of a list.
+func changePath(path []string) cue.Path {
+	sels := make([]cue.Selector, 0, len(path))
+	for _, s := range path {
+		if s == "[]" {
+			sels = append(sels, cue.AnyIndex)
+			continue
+		}
+		if uq, err := strconv.Unquote(s); err == nil {
+			s = uq
+		}
+		sels = append(sels, cue.Str(s))
+	}
+	return cue.MakePath(sels...)
+}
+
+// DefaultChangeError indicates that the defaults of one or more fields
+// changed between schemas.
+type DefaultChangeError struct {
+	// Changes contains each of the default changes, all of which are of kind
+	// DefaultChanged or DefaultRemoved.
+	Changes []Change
+}
+
+func (e *DefaultChangeError) Error() string {
+	strs := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		strs = append(strs, c.String())
+	}
+	return strings.Join(strs, "; ")
+}
+
+// CheckDefaults walks the fields of both schemas, comparing their defaults.
+// If the default of any field was changed or removed, a *DefaultChangeError
+// is returned naming each such field, along with its prior and next defaults.
+//
+// Default changes are not detectable via CUE subsumption, but alter the
+// meaning of existing data that relied on the prior default, and are
+// therefore backwards incompatible.
+func CheckDefaults(prior, next cue.Value) error {
+	return Diff(prior, next).defaultChanges()
+}
+
+// defaultChanges returns a *DefaultChangeError for the changed or removed
+// defaults in the report, if any.
+func (r *Report) defaultChanges() error {
+	var changes []Change
+	for _, c := range r.Changes {
+		if c.Kind == DefaultChanged || c.Kind == DefaultRemoved {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return &DefaultChangeError{
+		Changes: changes,
+	}
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) diff(prior, next cue.Value, path []string) {
+	pk, nk := prior.IncompleteKind(), next.IncompleteKind()
+	switch {
+	case pk == cue.StructKind && nk == cue.StructKind:
+		d.diffStruct(prior, next, path)
+	case pk == cue.ListKind && nk == cue.ListKind && hasElem(prior) && hasElem(next):
+		d.diffDefault(prior, next, path)
+		d.diff(prior.LookupPath(cue.MakePath(cue.AnyIndex)), next.LookupPath(cue.MakePath(cue.AnyIndex)), append(append([]string{}, path...), "[]"))
+	default:
+		d.diffLeaf(prior, next, path)
+	}
+}
+
+func (d *differ) diffStruct(prior, next cue.Value, path []string) {
+	iter, _ := next.Fields(cue.Optional(true))
+	for iter.Next() {
+		sel := iter.Selector()
+		fpath := appendPath(path, sel)
+		nf := iter.Value()
+
+		pf, popt, has := lookupField(prior, sel)
+		if !has {
+			_, hasd := defaultOf(nf)
+			d.add(Change{
+				Kind:     FieldAdded,
+				Path:     fpath,
+				Next:     str(nf),
+				Breaking: !iter.IsOptional() && !hasd,
+				Pos:      nf.Pos(),
+			})
+			continue
+		}
+
+		if popt != iter.IsOptional() {
+			c := Change{
+				Kind: MadeOptional,
+				Path: fpath,
+				Pos:  nf.Pos(),
+			}
+			if popt {
+				c.Kind, c.Breaking = MadeRequired, true
+			}
+			d.add(c)
+		}
+
+		d.diff(pf, nf, fpath)
+	}
+
+	iter, _ = prior.Fields(cue.Optional(true))
+	for iter.Next() {
+		if _, _, has := lookupField(next, iter.Selector()); !has {
+			d.add(Change{
+				Kind:     FieldRemoved,
+				Path:     appendPath(path, iter.Selector()),
+				Prior:    str(iter.Value()),
+				Breaking: true,
+				Pos:      iter.Value().Pos(),
+			})
+		}
+	}
+}
+
+func (d *differ) diffLeaf(prior, next cue.Value, path []string) {
+	d.diffDefault(prior, next, path)
+
+	widens, narrows := accepts(next, prior), accepts(prior, next)
+	c := Change{
+		Path:  path,
+		Prior: str(prior),
+		Next:  str(next),
+		Pos:   next.Pos(),
+	}
+	switch {
+	case widens && narrows:
+		return
+	case widens:
+		c.Kind = ConstraintWidened
+	case narrows:
+		c.Kind, c.Breaking = ConstraintNarrowed, true
+	default:
+		c.Kind, c.Breaking = ConstraintChanged, true
+	}
+	d.add(c)
+}
+
+func (d *differ) diffDefault(prior, next cue.Value, path []string) {
+	pd, phas := defaultOf(prior)
+	nd, nhas := defaultOf(next)
+	c := Change{
+		Path: path,
+		Pos:  next.Pos(),
+	}
+	switch {
+	case !phas && !nhas:
+		return
+	case !phas:
+		c.Kind, c.Next = DefaultAdded, str(nd)
+	case !nhas:
+		c.Kind, c.Prior, c.Breaking = DefaultRemoved, str(pd), true
+	case !pd.Equals(nd):
+		c.Kind, c.Prior, c.Next, c.Breaking = DefaultChanged, str(pd), str(nd), true
+	default:
+		return
+	}
+	d.add(c)
+}
+
+// accepts reports whether every value accepted by b is also accepted by a.
+//
+// Subsumption is checked individually for each branch of any disjunctions,
+// as CUE's subsumption of whole disjunctions is unreliable.
+func accepts(a, b cue.Value) bool {
+	abranches, bbranches := branches(a), branches(b)
+	for _, bb := range bbranches {
+		var ok bool
+		for _, ab := range abranches {
+			if ab.Subsume(bb, cue.Schema(), cue.Final()) == nil {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func branches(v cue.Value) []cue.Value {
+	if op, args := v.Expr(); op == cue.OrOp {
+		return args
+	}
+	return []cue.Value{v}
+}
+
+// defaultOf returns the explicitly marked default of the provided value, if
+// any. The implicit empty list default of open lists is ignored.
+func defaultOf(v cue.Value) (cue.Value, bool) {
+	dv, has := v.Default()
+	if !has || !dv.IsConcrete() {
+		return cue.Value{}, false
+	}
+	if op, _ := v.Expr(); v.IncompleteKind() == cue.ListKind && op != cue.OrOp {
+		if l, _ := dv.Len().Int64(); l == 0 {
+			return cue.Value{}, false
+		}
+	}
+	return dv, true
+}
+
+func hasElem(v cue.Value) bool {
+	return v.LookupPath(cue.MakePath(cue.AnyIndex)).Exists()
+}
+
+// lookupField finds the field, required or optional, with the provided
+// selector in the struct value, also indicating whether the field is optional.
+func lookupField(v cue.Value, sel cue.Selector) (cue.Value, bool, bool) {
+	iter, _ := v.Fields(cue.Optional(true))
+	for iter.Next() {
+		if iter.Selector().String() == sel.String() {
+			return iter.Value(), iter.IsOptional(), true
+		}
+	}
+	return cue.Value{}, false, false
+}
+
+func appendPath(path []string, sel cue.Selector) []string {
+	return append(append([]string{}, path...), sel.String())
+}
+
+func str(v cue.Value) string {
+	return fmt.Sprint(v)
+}
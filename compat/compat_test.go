@@ -0,0 +1,194 @@
+package compat
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+var diffstr = `
+prior: {
+	same:      string
+	removed:   int
+	nowopt:    string
+	nowreq?:   string
+	widen:     int & <10
+	narrow:    int
+	change:    string
+	enum:      "foo" | "bar"
+	defchange: *"foo" | "bar"
+	defadd:    int
+	list: [...string]
+	nested: {
+		a: bool
+	}
+}
+next: {
+	same:      string
+	nowopt?:   string
+	nowreq:    string
+	widen:     int
+	narrow:    int & <10
+	change:    int
+	enum:      "foo" | "bar" | "baz"
+	defchange: *"bar" | "foo"
+	defadd:    int | *42
+	list: [...(string | int)]
+	nested: {
+		a:  bool
+		b?: string
+		c:  string
+	}
+}
+`
+
+func TestDiff(t *testing.T) {
+	v := cuecontext.New().CompileString(diffstr)
+	if v.Err() != nil {
+		t.Fatal(v.Err())
+	}
+	r := Diff(v.LookupPath(cue.ParsePath("prior")), v.LookupPath(cue.ParsePath("next")))
+
+	expect := []struct {
+		path     string
+		kind     ChangeKind
+		breaking bool
+	}{
+		{"nowopt", MadeOptional, false},
+		{"nowreq", MadeRequired, true},
+		{"widen", ConstraintWidened, false},
+		{"narrow", ConstraintNarrowed, true},
+		{"change", ConstraintChanged, true},
+		{"enum", ConstraintWidened, false},
+		{"defchange", DefaultChanged, true},
+		{"defadd", DefaultAdded, false},
+		{"list.[]", ConstraintWidened, false},
+		{"nested.b", FieldAdded, false},
+		{"nested.c", FieldAdded, true},
+		{"removed", FieldRemoved, true},
+	}
+
+	if len(r.Changes) != len(expect) {
+		for _, c := range r.Changes {
+			t.Log(c)
+		}
+		t.Fatalf("expected %d changes, got %d", len(expect), len(r.Changes))
+	}
+	for i, ex := range expect {
+		c := r.Changes[i]
+		if p := strings.Join(c.Path, "."); p != ex.path || c.Kind != ex.kind || c.Breaking != ex.breaking {
+			t.Errorf("change %d: expected %s %s (breaking: %v), got %s %s (breaking: %v)", i, ex.path, ex.kind, ex.breaking, p, c.Kind, c.Breaking)
+		}
+	}
+	if !r.IsBreaking() {
+		t.Error("expected report to be breaking")
+	}
+	if n := len(r.Breaking()); n != 6 {
+		t.Errorf("expected 6 breaking changes, got %d", n)
+	}
+}
+
+func TestDiffCompatible(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+prior: {
+	a: string
+}
+next: {
+	a:  string
+	b?: int
+}
+`)
+	r := Diff(v.LookupPath(cue.ParsePath("prior")), v.LookupPath(cue.ParsePath("next")))
+	if r.IsBreaking() {
+		t.Fatalf("expected compatible report, got breaking changes: %v", r.Breaking())
+	}
+	if len(r.Changes) != 1 || r.Changes[0].Kind != FieldAdded {
+		t.Fatalf("unexpected changes: %v", r.Changes)
+	}
+}
+
+func TestCheckDefaults(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+prior: {
+	a: *"foo" | "bar"
+	b: int
+	c?: *true | false
+}
+next: {
+	a: "foo" | *"bar"
+	b: int | *4
+	c?: bool
+}
+`)
+	err := CheckDefaults(v.LookupPath(cue.ParsePath("prior")), v.LookupPath(cue.ParsePath("next")))
+	dcerr, ok := err.(*DefaultChangeError)
+	if !ok {
+		t.Fatalf("expected a *DefaultChangeError, got %v", err)
+	}
+	if len(dcerr.Changes) != 2 || dcerr.Changes[0].Kind != DefaultChanged || dcerr.Changes[1].Kind != DefaultRemoved {
+		t.Fatalf("unexpected default changes: %s", dcerr)
+	}
+
+	if err = CheckDefaults(v.LookupPath(cue.ParsePath("prior")), v.LookupPath(cue.ParsePath("prior"))); err != nil {
+		t.Fatalf("expected no default changes, got %s", err)
+	}
+}
+
+func TestThemaCompatible(t *testing.T) {
+	v := cuecontext.New().CompileString(`
+prior: {
+	a: string
+	l: [...{x: int}]
+}
+adddefault: {
+	a: string
+	b: *"foo" | "bar"
+	c: int | *42
+	l: [...{x: int}]
+}
+addrequired: {
+	a: string
+	b: string
+	l: [...{x: int}]
+}
+addoptional: {
+	a:  string
+	b?: string
+	l: [...{x: int}]
+}
+narrowwithdefault: {
+	a: "foo"
+	b: *"foo" | "bar"
+	l: [...{x: int}]
+}
+adddefaultinlist: {
+	a: string
+	l: [...{x: int, y: int | *1}]
+}
+adddefaultquoted: {
+	a: string
+	"b-c": int | *1
+	l: [...{x: int}]
+}
+`)
+
+	prior := v.LookupPath(cue.ParsePath("prior"))
+	table := map[string]bool{
+		"adddefault":        true,
+		"addrequired":       false,
+		"addoptional":       true,
+		"narrowwithdefault": false,
+		"adddefaultinlist":  true,
+		"adddefaultquoted":  true,
+	}
+	for name, compatible := range table {
+		err := ThemaCompatible(prior, v.LookupPath(cue.ParsePath(name)))
+		if compatible && err != nil {
+			t.Errorf("%s: expected compatible, got %s", name, err)
+		} else if !compatible && err == nil {
+			t.Errorf("%s: expected incompatible", name)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package thema
+
+import (
+	"fmt"
+	"sort"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/token"
+	terrors "github.com/grafana/thema/errors"
+)
+
+var (
+	_ Lineage            = &CompositeLineage{}
+	_ Schema             = &CompositeSchema{}
+	_ TranslationLacunas = CompositeTranslationLacunas{}
+)
+
+// A CompositeLineage is a lineage composed of other, independently versioned
+// member lineages. Each of its schemas is a join of its members' schemas, with
+// each member's schema appearing in the field named after that member.
+//
+// Composite lineages share a single version coordinate space with their
+// members. The set of versions in a composite lineage is the union of all of
+// its members' versions, and the composite schema at version v joins, for
+// each member, that member's newest schema with a version less than or equal
+// to v. Because each member upholds Thema's invariants, the composite does as
+// well: a composite schema is backwards compatible with its predecessor iff
+// none of its members changed sequence.
+//
+// Validation, translation, and lacuna aggregation are all performed member by
+// member.
+type CompositeLineage struct {
+	name    string
+	raw     cue.Value
+	rt      *Runtime
+	members []Lineage
+	allv    []SyntacticVersion
+	allsch  []*CompositeSchema
+}
+
+// NewCompositeLineage composes the provided member lineages into a
+// CompositeLineage with the given name.
+//
+// Members are keyed by their names, which must be unique. All members must
+// have been built with the same [Runtime].
+func NewCompositeLineage(name string, members ...Lineage) (*CompositeLineage, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: composite lineage must have a name", terrors.ErrInvalidLineage)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w: composite lineage %q must have at least one member", terrors.ErrInvalidLineage, name)
+	}
+
+	lin := &CompositeLineage{
+		name:    name,
+		rt:      getLinLib(members[0]),
+		members: members,
+	}
+
+	seen := make(map[string]bool)
+	for _, mlin := range members {
+		isValidLineage(mlin)
+		mname := mlin.Name()
+		if seen[mname] {
+			return nil, fmt.Errorf("%w: composite lineage %q has multiple members named %q", terrors.ErrInvalidLineage, name, mname)
+		}
+		seen[mname] = true
+
+		if getLinLib(mlin) != lin.rt {
+			return nil, fmt.Errorf("%w: member %q of composite lineage %q was built with a different thema.Runtime", terrors.ErrInvalidLineage, mname, name)
+		}
+
+		for _, v := range allVersions(mlin) {
+			if !synvExists(lin.allv, v) {
+				lin.allv = append(lin.allv, v)
+				sort.Slice(lin.allv, func(i, j int) bool { return lin.allv[i].Less(lin.allv[j]) })
+			}
+		}
+	}
+
+	lin.rt.l()
+	defer lin.rt.u()
+
+	ctx := lin.rt.Context()
+	lin.raw = ctx.CompileString("{}")
+	for _, mlin := range members {
+		lin.raw = lin.raw.FillPath(cue.MakePath(cue.Str(mlin.Name())), mlin.UnwrapCUE())
+	}
+
+	for _, v := range lin.allv {
+		sch := &CompositeSchema{
+			raw: ctx.CompileString("{}"),
+			lin: lin,
+			v:   v,
+		}
+		for _, mlin := range members {
+			msch := SchemaP(mlin, memberVersion(mlin, v))
+			sch.members = append(sch.members, msch)
+			sch.raw = sch.raw.FillPath(cue.MakePath(cue.Str(mlin.Name())), msch.UnwrapCUE())
+		}
+		lin.allsch = append(lin.allsch, sch)
+	}
+
+	return lin, nil
+}
+
+// memberVersion returns the version of the newest schema in the member lineage
+// that is less than or equal to the provided version.
+func memberVersion(mlin Lineage, v SyntacticVersion) SyntacticVersion {
+	mallv := allVersions(mlin)
+	i := searchSynv(mallv, v)
+	if i < len(mallv) && mallv[i] == v {
+		return v
+	}
+	// All lineages contain 0.0, so there is always a smaller version
+	return mallv[i-1]
+}
+
+// UnwrapCUE returns a cue.Value containing each of the composite lineage's
+// member lineages, in a field named after that member.
+func (lin *CompositeLineage) UnwrapCUE() cue.Value {
+	return lin.raw
+}
+
+// Name returns the name of the composite lineage, as provided to
+// [NewCompositeLineage].
+func (lin *CompositeLineage) Name() string {
+	return lin.name
+}
+
+// Members returns the composite lineage's member lineages, in the order they
+// were provided to [NewCompositeLineage].
+func (lin *CompositeLineage) Members() []Lineage {
+	return append([]Lineage(nil), lin.members...)
+}
+
+// Runtime returns the thema.Runtime instance with which all of the composite
+// lineage's members were built.
+func (lin *CompositeLineage) Runtime() *Runtime {
+	return lin.rt
+}
+
+// ValidateAny checks that the provided data is valid with respect to at
+// least one of the schemas in the lineage. The oldest (smallest) schema against
+// which the data validates is chosen. A nil return indicates no validating
+// schema was found.
+func (lin *CompositeLineage) ValidateAny(data cue.Value) *Instance {
+	for _, sch := range lin.allsch {
+		if inst, err := sch.Validate(data); err == nil {
+			return inst
+		}
+	}
+	return nil
+}
+
+// Schema returns the schema identified by the provided version, if one exists.
+//
+// Only the [0, 0] schema is guaranteed to exist in all valid lineages.
+func (lin *CompositeLineage) Schema(v SyntacticVersion) (Schema, error) {
+	if !synvExists(lin.allv, v) {
+		return nil, &ErrNoSchemaWithVersion{
+			lin: lin,
+			v:   v,
+		}
+	}
+
+	return lin.schema(v), nil
+}
+
+func (lin *CompositeLineage) schema(v SyntacticVersion) *CompositeSchema {
+	return lin.allsch[searchSynv(lin.allv, v)]
+}
+
+func (lin *CompositeLineage) _lineage() {}
+
+// A CompositeSchema is a schema from a [CompositeLineage], joining one schema
+// from each of the composite lineage's members.
+type CompositeSchema struct {
+	raw     cue.Value
+	lin     *CompositeLineage
+	v       SyntacticVersion
+	members []Schema
+}
+
+// Validate checks that the provided data is valid with respect to the
+// schema. If valid, the data is wrapped in an [Instance] and returned.
+// Otherwise, a nil Instance is returned along with an error detailing the
+// validation failure.
+//
+// The data must be a struct containing exactly one field per member lineage,
+// named after that member, and each of those fields must be valid with
+// respect to the member's schema.
+func (sch *CompositeSchema) Validate(data cue.Value) (*Instance, error) {
+	var errs validationFailure
+	addErr := func(err error) {
+		for it := IterValidationErrors(err); it.Next(); {
+			if ve := it.ValidationError(); ve != nil {
+				// Re-root the member's failure within the composite
+				nve := *ve
+				nve.Schema = sch
+				nve.Path = append([]string{ve.Schema.Lineage().Name()}, ve.Path...)
+				errs = append(errs, &nve)
+			} else {
+				errs = append(errs, it.Err())
+			}
+		}
+	}
+
+	if data.IncompleteKind() != cue.StructKind {
+		return nil, validationFailure{&ValidationError{
+			Schema:   sch,
+			DataPos:  []token.Pos{data.Pos()},
+			Expected: "struct",
+			Actual:   fmt.Sprint(data),
+			Code:     terrors.KindConflict,
+		}}
+	}
+
+	for _, msch := range sch.members {
+		mname := msch.Lineage().Name()
+		mdata := data.LookupPath(cue.MakePath(cue.Str(mname)))
+		if !mdata.Exists() {
+			errs = append(errs, &ValidationError{
+				Schema:    sch,
+				Path:      []string{mname},
+				SchemaPos: []token.Pos{msch.UnwrapCUE().Pos()},
+				Expected:  fmt.Sprintf("%s@v%s", mname, msch.Version()),
+				Code:      terrors.MissingField,
+			})
+			continue
+		}
+
+		if _, err := msch.Validate(mdata); err != nil {
+			addErr(err)
+		}
+	}
+
+	iter, _ := data.Fields()
+	for iter.Next() {
+		if !sch.raw.LookupPath(cue.MakePath(iter.Selector())).Exists() {
+			errs = append(errs, &ValidationError{
+				Schema:  sch,
+				Path:    []string{iter.Selector().String()},
+				DataPos: []token.Pos{iter.Value().Pos()},
+				Actual:  fmt.Sprint(iter.Value()),
+				Code:    terrors.ExcessField,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &Instance{
+		raw:  data,
+		sch:  sch,
+		name: "", // FIXME how are we getting this out?
+	}, nil
+}
+
+// Member returns the schema for the named member lineage that is joined into
+// this composite schema, or nil if the composite lineage has no such member.
+func (sch *CompositeSchema) Member(name string) Schema {
+	for _, msch := range sch.members {
+		if msch.Lineage().Name() == name {
+			return msch
+		}
+	}
+	return nil
+}
+
+// Successor returns the next schema in the lineage, or nil if it is the last schema.
+func (sch *CompositeSchema) Successor() Schema {
+	i := searchSynv(sch.lin.allv, sch.v)
+	if i == len(sch.lin.allv)-1 {
+		return nil
+	}
+	return sch.lin.allsch[i+1]
+}
+
+// Predecessor returns the previous schema in the lineage, or nil if it is the first schema.
+func (sch *CompositeSchema) Predecessor() Schema {
+	i := searchSynv(sch.lin.allv, sch.v)
+	if i == 0 {
+		return nil
+	}
+	return sch.lin.allsch[i-1]
+}
+
+// LatestVersionInSequence returns the version number of the newest (largest) schema
+// version in the provided sequence number.
+func (sch *CompositeSchema) LatestVersionInSequence() SyntacticVersion {
+	// Lineage invariants preclude an error
+	sv, _ := LatestVersionInSequence(sch.lin, sch.v[0])
+	return sv
+}
+
+// UnwrapCUE returns the cue.Value that represents the joined CUE schema.
+func (sch *CompositeSchema) UnwrapCUE() cue.Value {
+	return sch.raw
+}
+
+// Version returns the schema's version number.
+func (sch *CompositeSchema) Version() SyntacticVersion {
+	return sch.v
+}
+
+// Lineage returns the lineage that contains this schema.
+func (sch *CompositeSchema) Lineage() Lineage {
+	return sch.lin
+}
+
+func (sch *CompositeSchema) _schema() {}
+
+// translateComposite translates each member of the instance to the schema
+// joined into the target composite schema.
+func (i *Instance) translateComposite(to *CompositeSchema) (*Instance, TranslationLacunas, error) {
+	from, is := i.sch.(*CompositeSchema)
+	if !is || from.lin != to.lin {
+		return nil, nil, fmt.Errorf("%w: instance schema is not from composite lineage %q", terrors.ErrTranslationFailed, to.lin.Name())
+	}
+
+	rt := to.lin.rt
+	rt.rl()
+	raw := rt.Context().CompileString("{}")
+	rt.ru()
+
+	lac := CompositeTranslationLacunas{}
+	for idx, msch := range from.members {
+		mname := msch.Lineage().Name()
+		minst := &Instance{
+			raw:  i.raw.LookupPath(cue.MakePath(cue.Str(mname))),
+			name: i.name,
+			sch:  msch,
+		}
+
+		tinst, mlac, err := minst.TranslateE(to.members[idx].Version())
+		if err != nil {
+			return nil, nil, fmt.Errorf("member %q: %w", mname, err)
+		}
+
+		rt.rl()
+		raw = raw.FillPath(cue.MakePath(cue.Str(mname)), tinst.raw)
+		rt.ru()
+		lac = append(lac, memberLacunas{name: mname, lac: mlac})
+	}
+
+	return &Instance{
+		raw:  raw,
+		name: i.name,
+		sch:  to,
+	}, lac, nil
+}
+
+type memberLacunas struct {
+	name string
+	lac  TranslationLacunas
+}
+
+// CompositeTranslationLacunas are the lacunas emitted by translating an
+// instance of a [CompositeLineage], aggregated across each of its members.
+//
+// Field paths in the lacunas returned from its [TranslationLacunas] methods
+// are relative to the root of the composite instance, prefixed with the name
+// of the member that emitted them. Call Member to get a member's lacunas with
+// their original paths.
+type CompositeTranslationLacunas []memberLacunas
+
+// Member returns the lacunas emitted by translating the named member, or nil
+// if there is no such member.
+func (lac CompositeTranslationLacunas) Member(name string) TranslationLacunas {
+	for _, ml := range lac {
+		if ml.name == name {
+			return ml.lac
+		}
+	}
+	return nil
+}
+
+func (lac CompositeTranslationLacunas) AsList() []Lacuna {
+	var l []Lacuna
+	for _, ml := range lac {
+		for _, lc := range ml.lac.AsList() {
+			lc.SourceFields = prefixFieldRefs(ml.name, lc.SourceFields)
+			lc.TargetFields = prefixFieldRefs(ml.name, lc.TargetFields)
+			l = append(l, lc)
+		}
+	}
+	return l
+}
+
+func (lac CompositeTranslationLacunas) ByType(t LacunaType) []Lacuna {
+	return lacunasByType(lac.AsList(), t)
+}
+
+func (lac CompositeTranslationLacunas) HasPlaceholder() bool {
+	for _, ml := range lac {
+		if ml.lac.HasPlaceholder() {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixFieldRefs(prefix string, refs []FieldRef) []FieldRef {
+	if len(refs) == 0 {
+		return refs
+	}
+	nrefs := make([]FieldRef, 0, len(refs))
+	for _, ref := range refs {
+		ref.Path = prefix + "." + ref.Path
+		nrefs = append(nrefs, ref)
+	}
+	return nrefs
+}
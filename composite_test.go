@@ -0,0 +1,172 @@
+package thema
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+var minorlinstr = `
+name: "minor"
+seqs: [
+	{
+		schemas: [
+			{
+				c: int
+			},
+			{
+				c: int
+				d?: string
+			},
+		]
+	},
+]
+`
+
+func compositeForTest(t *testing.T) *CompositeLineage {
+	t.Helper()
+
+	rt := NewRuntime(cuecontext.New())
+	var members []Lineage
+	for _, linstr := range []string{placeholderlinstr, minorlinstr} {
+		val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(linstr))
+		lin, err := BindLineage(val, rt)
+		if err != nil {
+			t.Fatal(errors.Details(err, nil))
+		}
+		members = append(members, lin)
+	}
+
+	clin, err := NewCompositeLineage("doc", members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clin
+}
+
+func TestCompositeLineageVersions(t *testing.T) {
+	clin := compositeForTest(t)
+
+	if lv := LatestVersion(clin); lv != synv(1, 0) {
+		t.Fatalf("expected latest version 1.0, got %s", lv)
+	}
+	if lv, err := LatestVersionInSequence(clin, 1); err != nil || lv != synv(1, 0) {
+		t.Fatalf("expected latest version in sequence 1 to be 1.0, got %s (%v)", lv, err)
+	}
+	if _, err := clin.Schema(synv(1, 1)); err == nil {
+		t.Fatal("expected error for nonexistent composite schema version")
+	}
+
+	// The composite schema at 1.0 should join the newest member schemas
+	sch := SchemaP(clin, synv(1, 0)).(*CompositeSchema)
+	if mv := sch.Member("placeholder").Version(); mv != synv(1, 0) {
+		t.Errorf("expected placeholder member at 1.0, got %s", mv)
+	}
+	if mv := sch.Member("minor").Version(); mv != synv(0, 1) {
+		t.Errorf("expected minor member at 0.1, got %s", mv)
+	}
+	if sch.Predecessor().Version() != synv(0, 1) || sch.Successor() != nil {
+		t.Error("unexpected predecessor or successor of composite schema 1.0")
+	}
+}
+
+func TestCompositeLineageValidate(t *testing.T) {
+	clin := compositeForTest(t)
+	ctx := clin.Runtime().Context()
+
+	inst := clin.ValidateAny(ctx.CompileString(`{ placeholder: { a: "foo" }, minor: { c: 42 } }`))
+	if inst == nil {
+		t.Fatal("expected composite data to validate")
+	}
+	if inst.Schema().Version() != synv(0, 0) {
+		t.Fatalf("expected oldest validating schema 0.0, got %s", inst.Schema().Version())
+	}
+
+	_, err := SchemaP(clin, synv(0, 0)).Validate(ctx.CompileString(`{ placeholder: { a: 42 }, extra: true }`, cue.Filename("data.cue")))
+	if !stderrors.Is(err, terrors.ErrNotAnInstance) {
+		t.Fatalf("expected ErrNotAnInstance, got %v", err)
+	}
+
+	found := make(map[string]terrors.ValidationCode)
+	for it := IterValidationErrors(err); it.Next(); {
+		ve := it.ValidationError()
+		if ve == nil {
+			t.Fatalf("expected structured validation error, got %s", it.Err())
+		}
+		found[ve.Path[len(ve.Path)-1]] = ve.Code
+		if ve.Path[0] == "placeholder" && ve.Schema != SchemaP(clin, synv(0, 0)) {
+			t.Error("expected member validation error to be re-rooted on the composite schema")
+		}
+	}
+	for field, code := range map[string]terrors.ValidationCode{
+		"a":     terrors.KindConflict,
+		"minor": terrors.MissingField,
+		"extra": terrors.ExcessField,
+	} {
+		if found[field] != code {
+			t.Errorf("expected failure code %d on field %q, got %d", code, field, found[field])
+		}
+	}
+}
+
+func TestCompositeLineageTranslate(t *testing.T) {
+	clin := compositeForTest(t)
+	ctx := clin.Runtime().Context()
+
+	inst, err := SchemaP(clin, synv(0, 0)).Validate(ctx.CompileString(`{ placeholder: { a: "foo" }, minor: { c: 42 } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tinst, lac, err := inst.TranslateE(synv(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tinst.Schema().Version() != synv(1, 0) {
+		t.Fatalf("expected translated instance at 1.0, got %s", tinst.Schema().Version())
+	}
+	if _, err = tinst.Schema().Validate(tinst.UnwrapCUE()); err != nil {
+		t.Fatalf("translated instance is not valid against its schema: %s", err)
+	}
+	b, _ := tinst.UnwrapCUE().LookupPath(cue.ParsePath("placeholder.b")).String()
+	if b != "placeholder" {
+		t.Fatalf("expected lens to populate placeholder.b, got %q", b)
+	}
+
+	if !lac.HasPlaceholder() {
+		t.Fatal("expected composite translation to report a placeholder")
+	}
+	pl := lac.ByType(LacunaPlaceholder)
+	if len(pl) != 1 || pl[0].TargetFields[0].Path != "placeholder.b" {
+		t.Fatalf("unexpected placeholder lacunas: %v", pl)
+	}
+
+	clac := lac.(CompositeTranslationLacunas)
+	if ml := clac.Member("placeholder").AsList(); len(ml) != 1 || ml[0].TargetFields[0].Path != "b" {
+		t.Fatalf("unexpected placeholder member lacunas: %v", ml)
+	}
+	if ml := clac.Member("minor").AsList(); len(ml) != 0 {
+		t.Fatalf("unexpected minor member lacunas: %v", ml)
+	}
+}
+
+func TestNewCompositeLineageErrors(t *testing.T) {
+	clin := compositeForTest(t)
+	members := clin.Members()
+
+	if _, err := NewCompositeLineage("doc"); !stderrors.Is(err, terrors.ErrInvalidLineage) {
+		t.Errorf("expected ErrInvalidLineage for composite with no members, got %v", err)
+	}
+	if _, err := NewCompositeLineage("doc", members[0], members[0]); !stderrors.Is(err, terrors.ErrInvalidLineage) {
+		t.Errorf("expected ErrInvalidLineage for duplicate members, got %v", err)
+	}
+
+	other := compositeForTest(t)
+	if _, err := NewCompositeLineage("doc", members[0], other.Members()[1]); !stderrors.Is(err, terrors.ErrInvalidLineage) {
+		t.Errorf("expected ErrInvalidLineage for members from different runtimes, got %v", err)
+	}
+}
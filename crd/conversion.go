@@ -0,0 +1,300 @@
+package crd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+	"github.com/grafana/thema/vmux"
+)
+
+// LacunaAnnotation is the key of the metadata annotation in which the lacunas
+// emitted while converting an object are reported, encoded as JSON.
+const LacunaAnnotation = "thema.grafana.com/lacunas"
+
+// ConversionReview mirrors the apiextensions.k8s.io/v1 ConversionReview type,
+// which is both the request and response body of a CRD conversion webhook.
+//
+// Only the fields relevant to conversion are represented.
+type ConversionReview struct {
+	APIVersion string              `json:"apiVersion"`
+	Kind       string              `json:"kind"`
+	Request    *ConversionRequest  `json:"request,omitempty"`
+	Response   *ConversionResponse `json:"response,omitempty"`
+}
+
+// ConversionRequest mirrors the apiextensions.k8s.io/v1 ConversionRequest type.
+type ConversionRequest struct {
+	// UID is an identifier for the individual request/response.
+	UID string `json:"uid"`
+	// DesiredAPIVersion is the version to convert given objects to, e.g.
+	// "example.com/v1-0".
+	DesiredAPIVersion string `json:"desiredAPIVersion"`
+	// Objects is the list of custom resource objects to be converted.
+	Objects []json.RawMessage `json:"objects"`
+}
+
+// ConversionResponse mirrors the apiextensions.k8s.io/v1 ConversionResponse type.
+type ConversionResponse struct {
+	// UID is an identifier for the individual request/response. It is copied
+	// from the corresponding ConversionRequest.
+	UID string `json:"uid"`
+	// ConvertedObjects is the list of converted objects, in the same order as
+	// the request's Objects.
+	ConvertedObjects []json.RawMessage `json:"convertedObjects"`
+	// Result contains the result of conversion.
+	Result Status `json:"result"`
+}
+
+// Status mirrors the subset of the Kubernetes metav1.Status type used in
+// ConversionResponse.Result.
+type Status struct {
+	// Status is either "Success" or "Failure".
+	Status string `json:"status"`
+	// Message is a human-readable description of the failure.
+	Message string `json:"message,omitempty"`
+}
+
+// VersionName returns the CRD version name corresponding to the provided
+// [thema.SyntacticVersion], e.g. "v1-0". This is consistent with the version
+// names produced by the #CRD definition.
+//
+// Kubernetes requires version names to be DNS-1035 labels, which may not
+// contain dots, so the sequence and schema numbers are separated by a hyphen.
+func VersionName(v thema.SyntacticVersion) string {
+	return fmt.Sprintf("v%d-%d", v[0], v[1])
+}
+
+// ParseVersionName parses a CRD version name (e.g. "v1-0") into a
+// [thema.SyntacticVersion].
+func ParseVersionName(name string) (thema.SyntacticVersion, error) {
+	if !strings.HasPrefix(name, "v") {
+		return thema.SV(0, 0), fmt.Errorf("%w: CRD version name %q must begin with \"v\"", terrors.ErrMalformedSyntacticVersion, name)
+	}
+	if strings.Count(name, "-") != 1 || strings.Contains(name, ".") {
+		return thema.SV(0, 0), fmt.Errorf("%w: CRD version name %q must be of the form v<seq>-<schema>", terrors.ErrMalformedSyntacticVersion, name)
+	}
+	return thema.ParseSyntacticVersion(strings.Replace(name[1:], "-", ".", 1))
+}
+
+// ParseAPIVersion splits a Kubernetes apiVersion string (e.g.
+// "example.com/v1-0") into its group and [thema.SyntacticVersion].
+func ParseAPIVersion(apiVersion string) (string, thema.SyntacticVersion, error) {
+	var group string
+	if i := strings.LastIndex(apiVersion, "/"); i != -1 {
+		group, apiVersion = apiVersion[:i], apiVersion[i+1:]
+	}
+	v, err := ParseVersionName(apiVersion)
+	return group, v, err
+}
+
+// Converter converts custom resource objects between the versions of a
+// lineage, as requested by a Kubernetes apiserver via a ConversionReview.
+//
+// The lineage schemas are expected to describe custom resource objects with the
+// apiVersion, kind, and metadata fields removed. Those fields are stripped
+// prior to validation and translation, then restored on the converted object.
+type Converter struct {
+	lin thema.Lineage
+
+	// CUE evaluation is not safe for concurrent use.
+	mut sync.Mutex
+}
+
+var _ http.Handler = &Converter{}
+
+// NewConverter creates a [Converter] for the provided lineage.
+func NewConverter(lin thema.Lineage) *Converter {
+	return &Converter{
+		lin: lin,
+	}
+}
+
+// Convert handles the request in the provided ConversionReview, returning a
+// new ConversionReview containing the corresponding response.
+//
+// Failure to convert any single object fails the whole request, as expected by
+// Kubernetes. Such failures are reported in the response's Result, rather than
+// as an error. An error is returned only if the review contains no request.
+func (c *Converter) Convert(review *ConversionReview) (*ConversionReview, error) {
+	if review.Request == nil {
+		return nil, fmt.Errorf("ConversionReview contains no request")
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	resp := &ConversionResponse{
+		UID:              review.Request.UID,
+		ConvertedObjects: make([]json.RawMessage, 0, len(review.Request.Objects)),
+		Result: Status{
+			Status: "Success",
+		},
+	}
+
+	for i, obj := range review.Request.Objects {
+		cobj, err := c.convertObject(obj, review.Request.DesiredAPIVersion)
+		if err != nil {
+			resp.ConvertedObjects = nil
+			resp.Result = Status{
+				Status:  "Failure",
+				Message: fmt.Sprintf("failed to convert object %d: %s", i, err),
+			}
+			break
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, cobj)
+	}
+
+	return &ConversionReview{
+		APIVersion: review.APIVersion,
+		Kind:       review.Kind,
+		Response:   resp,
+	}, nil
+}
+
+func (c *Converter) convertObject(obj json.RawMessage, desired string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(obj, &fields); err != nil {
+		return nil, fmt.Errorf("object is not a JSON object: %w", err)
+	}
+
+	var apiVersion string
+	if err := json.Unmarshal(fields["apiVersion"], &apiVersion); err != nil {
+		return nil, fmt.Errorf("object has no valid apiVersion: %w", err)
+	}
+	_, from, err := ParseAPIVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := ParseAPIVersion(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := c.lin.Schema(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", terrors.ErrVersionNotExist, err)
+	}
+
+	// Separate Kubernetes object fields from the Thema instance
+	metadata := fields["metadata"]
+	kind := fields["kind"]
+	delete(fields, "apiVersion")
+	delete(fields, "kind")
+	delete(fields, "metadata")
+
+	byt, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	datv, err := vmux.NewJSONEndec(apiVersion).Decode(c.lin.Runtime().Context(), byt)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := sch.Validate(datv)
+	if err != nil {
+		return nil, err
+	}
+	tinst, lac, err := inst.TranslateE(to)
+	if err != nil {
+		return nil, err
+	}
+
+	byt, err = json.Marshal(tinst.UnwrapCUE())
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling translated object to JSON: %w", err)
+	}
+	var out map[string]json.RawMessage
+	if err = json.Unmarshal(byt, &out); err != nil {
+		return nil, fmt.Errorf("translated object is not a JSON object: %w", err)
+	}
+
+	out["apiVersion"], _ = json.Marshal(desired)
+	if kind != nil {
+		out["kind"] = kind
+	}
+	metadata, err = annotateLacunas(metadata, lac)
+	if err != nil {
+		return nil, err
+	}
+	if metadata != nil {
+		out["metadata"] = metadata
+	}
+
+	return json.Marshal(out)
+}
+
+// annotateLacunas adds the JSON-encoded lacunas to the annotations in the
+// provided object metadata, if there are any. If there are none, any lacuna
+// annotation left by a prior conversion is removed, as it no longer describes
+// the object.
+func annotateLacunas(metadata json.RawMessage, lac thema.TranslationLacunas) (json.RawMessage, error) {
+	var meta map[string]interface{}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &meta); err != nil {
+			return nil, fmt.Errorf("object metadata is not a JSON object: %w", err)
+		}
+	}
+	annotations, _ := meta["annotations"].(map[string]interface{})
+
+	if lac == nil || len(lac.AsList()) == 0 {
+		if _, has := annotations[LacunaAnnotation]; !has {
+			return metadata, nil
+		}
+		delete(annotations, LacunaAnnotation)
+		if len(annotations) == 0 {
+			delete(meta, "annotations")
+		}
+		return json.Marshal(meta)
+	}
+
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	if annotations == nil {
+		annotations = make(map[string]interface{})
+	}
+
+	lacb, err := json.Marshal(lac.AsList())
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling lacunas to JSON: %w", err)
+	}
+	annotations[LacunaAnnotation] = string(lacb)
+	meta["annotations"] = annotations
+
+	return json.Marshal(meta)
+}
+
+// ServeHTTP implements [http.Handler], allowing a Converter to be directly used
+// as a Kubernetes CRD conversion webhook.
+func (c *Converter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var review ConversionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("invalid ConversionReview: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := c.Convert(&review)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(byt) //nolint:errcheck
+}
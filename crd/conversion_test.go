@@ -0,0 +1,194 @@
+package crd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/exemplars"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionName(t *testing.T) {
+	v, err := ParseVersionName("v1-0")
+	require.NoError(t, err)
+	require.Equal(t, thema.SV(1, 0), v)
+	require.Equal(t, "v1-0", VersionName(v))
+
+	group, v, err := ParseAPIVersion("example.com/v0-2")
+	require.NoError(t, err)
+	require.Equal(t, "example.com", group)
+	require.Equal(t, thema.SV(0, 2), v)
+
+	_, err = ParseVersionName("1-0")
+	require.Error(t, err)
+	_, err = ParseVersionName("v1.0")
+	require.Error(t, err)
+	_, err = ParseVersionName("v1-0-0")
+	require.Error(t, err)
+}
+
+var renameReview = `{
+	"apiVersion": "apiextensions.k8s.io/v1",
+	"kind": "ConversionReview",
+	"request": {
+		"uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+		"desiredAPIVersion": "example.com/v1-0",
+		"objects": [
+			{
+				"apiVersion": "example.com/v0-0",
+				"kind": "Rename",
+				"metadata": {
+					"name": "obj1",
+					"namespace": "default"
+				},
+				"before": "foo",
+				"unchanged": "bar"
+			}
+		]
+	}
+}`
+
+var renameConverted = `{
+	"after": "foo",
+	"apiVersion": "example.com/v1-0",
+	"kind": "Rename",
+	"metadata": {
+		"name": "obj1",
+		"namespace": "default"
+	},
+	"unchanged": "bar"
+}`
+
+func TestConverter(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.RenameLineage(rt)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(NewConverter(lin))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(renameReview))
+	require.NoError(t, err)
+	defer resp.Body.Close() // nolint: errcheck
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var review ConversionReview
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+	require.NotNil(t, review.Response)
+	require.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800002", review.Response.UID)
+	require.Equal(t, "Success", review.Response.Result.Status, review.Response.Result.Message)
+	require.Len(t, review.Response.ConvertedObjects, 1)
+	require.JSONEq(t, renameConverted, string(review.Response.ConvertedObjects[0]))
+}
+
+func TestConverterFailure(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.RenameLineage(rt)
+	require.NoError(t, err)
+
+	var review ConversionReview
+	require.NoError(t, json.Unmarshal([]byte(strings.Replace(renameReview, "example.com/v1-0", "example.com/v4-2", 1)), &review))
+
+	out, err := NewConverter(lin).Convert(&review)
+	require.NoError(t, err)
+	require.Equal(t, "Failure", out.Response.Result.Status)
+	require.Empty(t, out.Response.ConvertedObjects)
+}
+
+var narrowingReview = `{
+	"apiVersion": "apiextensions.k8s.io/v1",
+	"kind": "ConversionReview",
+	"request": {
+		"uid": "705ab4f5-6393-11e8-b7cc-42010a800003",
+		"desiredAPIVersion": "example.com/v1-0",
+		"objects": [
+			{
+				"apiVersion": "example.com/v0-0",
+				"kind": "Narrowing",
+				"metadata": {
+					"name": "obj1",
+					"annotations": {
+						"example.com/other": "keep"
+					}
+				},
+				"boolish": "maybe"
+			}
+		]
+	}
+}`
+
+func TestConverterLacunaAnnotation(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.NarrowingLineage(rt)
+	require.NoError(t, err)
+
+	var review ConversionReview
+	require.NoError(t, json.Unmarshal([]byte(narrowingReview), &review))
+
+	out, err := NewConverter(lin).Convert(&review)
+	require.NoError(t, err)
+	require.Equal(t, "Success", out.Response.Result.Status, out.Response.Result.Message)
+	require.Len(t, out.Response.ConvertedObjects, 1)
+
+	var obj struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+		Properbool bool `json:"properbool"`
+	}
+	require.NoError(t, json.Unmarshal(out.Response.ConvertedObjects[0], &obj))
+	require.False(t, obj.Properbool)
+	require.Equal(t, "keep", obj.Metadata.Annotations["example.com/other"])
+
+	var lacunas []thema.Lacuna
+	require.NoError(t, json.Unmarshal([]byte(obj.Metadata.Annotations[LacunaAnnotation]), &lacunas))
+	require.Len(t, lacunas, 1)
+	require.Equal(t, thema.LacunaLossyFieldMapping, lacunas[0].Type)
+	require.Equal(t, "boolish", lacunas[0].SourceFields[0].Path)
+}
+
+func TestConverterRemovesStaleLacunaAnnotation(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.RenameLineage(rt)
+	require.NoError(t, err)
+
+	table := []struct {
+		name        string
+		annotations string
+		want        string
+	}{
+		{
+			name:        "onlyLacunas",
+			annotations: `{"thema.grafana.com/lacunas": "[{\"type\":\"Placeholder\"}]"}`,
+			want:        `{"name": "obj1", "namespace": "default"}`,
+		},
+		{
+			name:        "withOthers",
+			annotations: `{"thema.grafana.com/lacunas": "[{\"type\":\"Placeholder\"}]", "example.com/other": "keep"}`,
+			want:        `{"name": "obj1", "namespace": "default", "annotations": {"example.com/other": "keep"}}`,
+		},
+	}
+
+	for _, tt := range table {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			reviewJSON := strings.Replace(renameReview, `"namespace": "default"`, `"namespace": "default", "annotations": `+tt.annotations, 1)
+			var review ConversionReview
+			require.NoError(t, json.Unmarshal([]byte(reviewJSON), &review))
+
+			out, err := NewConverter(lin).Convert(&review)
+			require.NoError(t, err)
+			require.Equal(t, "Success", out.Response.Result.Status, out.Response.Result.Message)
+			require.Len(t, out.Response.ConvertedObjects, 1)
+
+			var obj map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(out.Response.ConvertedObjects[0], &obj))
+			require.JSONEq(t, tt.want, string(obj["metadata"]))
+		})
+	}
+}
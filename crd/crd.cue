@@ -1,16 +1,17 @@
 package crd
 
 import (
-    "list"
     "strings"
     "github.com/grafana/thema"
 )
 
 // CRD transforms a lineage into a Kubernetes custom resource definition, or a series thereof.
 #CRD: {
-    _sv: [<len(lin.seqs), <len(lin.seqs[_sv[0]].schemas)]
-    served: [..._sv]
-    storage: _sv
+    // TODO constrain schema index to the length of the referenced sequence
+    // without a self-reference, which makes served and storage incomplete
+    _sv: [<len(lin.seqs), int & >=0]
+    Served=served: [..._sv]
+    Storage=storage: _sv
     lin: thema.#Lineage
 
     // Additional metadata necessary to convert a thema lineage into a
@@ -44,7 +45,7 @@ import (
             // plural is the plural name of the resource to serve. The custom
             // resources are served under
             // `/apis/<group>/<version>/.../<plural>`.
-            plural: string | =~ #"[a-z]"# | *"\(lin.name)s"
+            plural: string | =~ #"[a-z]"# | *strings.ToLower("\(lin.name)s")
 
             // shortNames allow shorter string to match your resource on the CLI
             shortNames?: [...string]
@@ -60,10 +61,31 @@ import (
 
 		// conversion defines conversion settings for the CRD.
         conversion?: {
-            // TODO for now, only allow this and not webhook, because what we
-            // really want to do is swap thema translation logic in for
-            // Scheme
-            strategy: "None"
+            // None only changes the apiVersion of objects. Webhook relies
+            // on Thema translation, served by the Converter in the Go
+            // package github.com/grafana/thema/crd.
+            strategy: *"None" | "Webhook"
+
+            if strategy == "Webhook" {
+                webhook: {
+                    // clientConfig is the instructions for how to call the
+                    // webhook.
+                    clientConfig: {
+                        url?: string
+                        service?: {
+                            namespace: string
+                            name: string
+                            path?: string
+                            port?: int
+                        }
+                        caBundle?: string
+                    }
+
+                    // conversionReviewVersions is an ordered list of
+                    // preferred ConversionReview versions.
+                    conversionReviewVersions: [...string] | *["v1"]
+                }
+            }
         }
     }
 
@@ -78,12 +100,15 @@ import (
         spec: versions: [
             for seqv, seq in lin.seqs {
                 for schv, sch in seq.schemas {
-                    served: list.Contains(served, [seqv, schv])
-                    storage: [seqv, schv] == storage
-                    name: "v\(seqv).\(schv)" // Not sure if the dot is allowed
+                    served: len([for sv in Served if sv[0] == seqv && sv[1] == schv {sv}]) > 0
+                    storage: Storage[0] == seqv && Storage[1] == schv
+                    name: "v\(seqv)-\(schv)"
                     schema: {
-                        openAPIV3Schema: {...} // This is what needs to be filled in by the encoder
-                        cueSchema: sch
+                        // Filled in by the encoder in the Go package
+                        // github.com/grafana/thema/encoding/crd
+                        openAPIV3Schema: {...}
+                        // Hidden, as Kubernetes rejects unknown fields in CRDs
+                        _cueSchema: sch
                     }
                 }
             }
@@ -0,0 +1,9 @@
+// Package crd provides tools for putting Thema lineages to work as Kubernetes
+// custom resources.
+//
+// The CUE package of the same name contains the #CRD definition, which
+// transforms a lineage into a CustomResourceDefinition. This Go package
+// provides the runtime counterpart: a conversion webhook that relies on Thema
+// translation, rather than a hand-written conversion Scheme, to convert
+// custom resource objects between their served versions.
+package crd
@@ -0,0 +1,203 @@
+// Package crd provides tools for generating Kubernetes CustomResourceDefinitions
+// from Thema lineages.
+package crd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	cueastutil "cuelang.org/go/cue/ast/astutil"
+	"cuelang.org/go/cue/load"
+	cueopenapi "cuelang.org/go/encoding/openapi"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/openapi"
+	"github.com/grafana/thema/internal/astutil"
+	"github.com/grafana/thema/internal/util"
+)
+
+// Config governs the behavior of [GenerateCRD].
+type Config struct {
+	// Group is the API group of the custom resource (e.g. stable.example.com).
+	// Required.
+	Group string
+
+	// Scope is either "Namespaced" or "Cluster". Defaults to "Namespaced".
+	Scope string
+
+	// Kind is the serialized kind of the custom resource. Defaults to the
+	// lineage name.
+	Kind string
+
+	// ListKind is the serialized kind of lists of the custom resource. Defaults
+	// to Kind suffixed with "List".
+	ListKind string
+
+	// Plural is the plural name of the custom resource. Defaults to the
+	// lowercase lineage name, suffixed with "s".
+	Plural string
+
+	// Singular is the singular name of the custom resource. Defaults to the
+	// lowercase Kind.
+	Singular string
+
+	// ShortNames are shorter names that match the custom resource on the CLI.
+	ShortNames []string
+
+	// Categories are grouped resources to which the custom resource belongs.
+	Categories []string
+
+	// Served is the list of schema versions to be served. If nil, all schema
+	// versions in the lineage are served.
+	Served []thema.SyntacticVersion
+
+	// Storage is the schema version to use for persisting custom resource
+	// objects. If nil, the latest schema version in the lineage is used.
+	Storage *thema.SyntacticVersion
+
+	// WebhookURL, if non-empty, sets the CRD's conversion strategy to "Webhook",
+	// pointing at a conversion webhook served at the provided URL - for
+	// example, by a Converter from the github.com/grafana/thema/crd Go package.
+	WebhookURL string
+}
+
+// GenerateCRD creates a Kubernetes apiextensions.k8s.io/v1
+// CustomResourceDefinition from the provided lineage, with one CRD version per
+// schema version in the lineage.
+//
+// The #CRD definition in the github.com/grafana/thema/crd CUE package provides
+// the structure of the CRD. The openAPIV3Schema for each version is generated
+// from the corresponding Thema schema using [openapi.GenerateSchema].
+//
+// Returns the result as a CUE AST, which is suitable for direct manipulation and
+// marshaling to either JSON or YAML.
+func GenerateCRD(lin thema.Lineage, cfg *Config) (*ast.File, error) {
+	if cfg == nil || cfg.Group == "" {
+		return nil, fmt.Errorf("must provide a group for the CRD")
+	}
+
+	ctx := lin.Runtime().Context()
+	pkg, err := buildCRDPackage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var allv []thema.SyntacticVersion
+	for sch := thema.SchemaP(lin, thema.SV(0, 0)); sch != nil; sch = sch.Successor() {
+		allv = append(allv, sch.Version())
+	}
+
+	served := cfg.Served
+	if served == nil {
+		served = allv
+	}
+	storage := thema.LatestVersion(lin)
+	if cfg.Storage != nil {
+		storage = *cfg.Storage
+	}
+	for _, v := range append([]thema.SyntacticVersion{storage}, served...) {
+		if _, err = lin.Schema(v); err != nil {
+			return nil, err
+		}
+	}
+
+	spec := map[string]interface{}{
+		"group": cfg.Group,
+	}
+	if cfg.Scope != "" {
+		spec["scope"] = cfg.Scope
+	} else {
+		spec["scope"] = "Namespaced"
+	}
+	names := make(map[string]interface{})
+	for k, s := range map[string]string{
+		"kind":     cfg.Kind,
+		"listKind": cfg.ListKind,
+		"plural":   cfg.Plural,
+		"singular": cfg.Singular,
+	} {
+		if s != "" {
+			names[k] = s
+		}
+	}
+	if len(cfg.ShortNames) > 0 {
+		names["shortNames"] = cfg.ShortNames
+	}
+	if len(cfg.Categories) > 0 {
+		names["categories"] = cfg.Categories
+	}
+	spec["names"] = names
+	if cfg.WebhookURL != "" {
+		spec["conversion"] = map[string]interface{}{
+			"strategy": "Webhook",
+			"webhook": map[string]interface{}{
+				"clientConfig": map[string]interface{}{
+					"url": cfg.WebhookURL,
+				},
+			},
+		}
+	}
+
+	def := pkg.LookupPath(cue.MakePath(cue.Def("#CRD"))).
+		FillPath(cue.MakePath(cue.Str("lin")), lin.UnwrapCUE()).
+		FillPath(cue.MakePath(cue.Str("served")), served).
+		FillPath(cue.MakePath(cue.Str("storage")), storage).
+		FillPath(cue.MakePath(cue.Str("spec")), spec)
+
+	crd := def.LookupPath(cue.MakePath(cue.Str("crd")))
+	for i, v := range allv {
+		oapi, err := openAPIV3Schema(thema.SchemaP(lin, v))
+		if err != nil {
+			return nil, fmt.Errorf("error generating openAPIV3Schema for %s: %w", v, err)
+		}
+		crd = crd.FillPath(cue.MakePath(cue.Str("spec"), cue.Str("versions"), cue.Index(i), cue.Str("schema"), cue.Str("openAPIV3Schema")), oapi)
+	}
+
+	if err = crd.Validate(cue.Concrete(true)); err != nil {
+		return nil, err
+	}
+
+	return cueastutil.ToFile(astutil.ToExpr(crd.Syntax(cue.Final(), cue.Concrete(true))))
+}
+
+// openAPIV3Schema generates the OpenAPI schema component for a single Thema
+// schema, with all references expanded, as CRDs do not allow $ref.
+func openAPIV3Schema(sch thema.Schema) (cue.Value, error) {
+	f, err := openapi.GenerateSchema(sch, &cueopenapi.Config{
+		ExpandReferences: true,
+	})
+	if err != nil {
+		return cue.Value{}, err
+	}
+
+	v := sch.Lineage().Runtime().Context().BuildFile(f)
+	if v.Err() != nil {
+		return cue.Value{}, v.Err()
+	}
+	comp := v.LookupPath(cue.MakePath(cue.Str("components"), cue.Str("schemas"), cue.Str(sch.Lineage().Name())))
+	if !comp.Exists() {
+		return cue.Value{}, fmt.Errorf("generated OpenAPI contains no schema component for %q", sch.Lineage().Name())
+	}
+	return comp, nil
+}
+
+func buildCRDPackage(ctx *cue.Context) (cue.Value, error) {
+	overlay := make(map[string]load.Source)
+	if err := util.ToOverlay(util.Prefix, thema.CueJointFS, overlay); err != nil {
+		return cue.Value{}, err
+	}
+
+	cfg := &load.Config{
+		Overlay: overlay,
+		Module:  "github.com/grafana/thema",
+		Dir:     filepath.Join(util.Prefix, "crd"),
+		Package: "crd",
+	}
+
+	v := ctx.BuildInstance(load.Instances(nil, cfg)[0])
+	if v.Err() != nil {
+		return cue.Value{}, v.Err()
+	}
+	return v, nil
+}
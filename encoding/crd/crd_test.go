@@ -0,0 +1,68 @@
+package crd
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/exemplars"
+)
+
+func TestGenerateCRD(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.RenameLineage(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := GenerateCRD(lin, &Config{
+		Group: "example.com",
+	})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	crd := rt.Context().BuildFile(f)
+	if crd.Err() != nil {
+		t.Fatal(crd.Err())
+	}
+
+	name, _ := crd.LookupPath(cue.ParsePath("metadata.name")).String()
+	if name != "renames.example.com" {
+		t.Fatalf("unexpected CRD name %q", name)
+	}
+
+	table := map[int]struct {
+		name, prop string
+		storage    bool
+	}{
+		0: {name: "v0-0", prop: "before", storage: false},
+		1: {name: "v1-0", prop: "after", storage: true},
+	}
+	for i, want := range table {
+		ver := crd.LookupPath(cue.MakePath(cue.Str("spec"), cue.Str("versions"), cue.Index(i)))
+		if s, _ := ver.LookupPath(cue.ParsePath("name")).String(); s != want.name {
+			t.Errorf("expected version %d to have name %q, got %q", i, want.name, s)
+		}
+		if b, _ := ver.LookupPath(cue.ParsePath("storage")).Bool(); b != want.storage {
+			t.Errorf("expected version %d to have storage %v, got %v", i, want.storage, b)
+		}
+		if !ver.LookupPath(cue.ParsePath("schema.openAPIV3Schema.properties." + want.prop)).Exists() {
+			t.Errorf("expected openAPIV3Schema for version %d to contain property %q", i, want.prop)
+		}
+	}
+}
+
+func TestGenerateCRDNoGroup(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.RenameLineage(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = GenerateCRD(lin, &Config{}); err == nil {
+		t.Fatal("expected error when no group is provided")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/internal/astutil"
 	"github.com/grafana/thema/internal/compat"
@@ -50,6 +51,64 @@ func NewLineage(sch cue.Value, name, pkgname string) (*ast.File, error) {
 	return f, nil
 }
 
+// NewLineageFromHistory constructs a CUE ast.File with a new lineage
+// declaration in it, containing each of the provided schemas in order. The
+// name and pkgname parameters are as in [NewLineage].
+//
+// The version of each schema is determined by its compatibility with its
+// predecessor: backwards compatible schemas are appended to the latest sequence
+// (minor version bump), and incompatible schemas start a new sequence (major
+// version bump), along with a lens skeleton as generated by [LensSkeleton].
+// The version assigned to each schema is returned.
+func NewLineageFromHistory(schs []cue.Value, name, pkgname string) (*ast.File, []thema.SyntacticVersion, error) {
+	if len(schs) == 0 {
+		return nil, nil, fmt.Errorf("must provide at least one schema")
+	}
+
+	f, err := NewLineage(schs[0], name, pkgname)
+	if err != nil {
+		return nil, nil, err
+	}
+	seql := astutil.FindSeqs(f)
+	if seql == nil {
+		return nil, nil, fmt.Errorf("could not find seqs list in generated lineage")
+	}
+	// Replace the first schema with itself, so that it is marked with its
+	// version like all the others
+	if err = InsertSchemaNodeAs(f, astutil.ToExpr(astutil.Format(schs[0])), thema.SyntacticVersion{0, 0}); err != nil {
+		return nil, nil, err
+	}
+
+	versions := []thema.SyntacticVersion{{0, 0}}
+	for i, sch := range schs[1:] {
+		prior, priorv := schs[i], versions[i]
+		schnode := astutil.ToExpr(astutil.Format(sch))
+
+		if err := compat.ThemaCompatible(prior, sch); err == nil {
+			v := thema.SyntacticVersion{priorv[0], priorv[1] + 1}
+			if err := InsertSchemaNodeAs(f, schnode, v); err != nil {
+				return nil, nil, err
+			}
+			versions = append(versions, v)
+			continue
+		}
+
+		v := thema.SyntacticVersion{priorv[0] + 1, 0}
+		if err := InsertSchemaNodeAs(f, schnode, v); err != nil {
+			return nil, nil, err
+		}
+		lens, err := LensSkeleton(prior, sch, priorv)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error generating lens to %s: %w", v, err)
+		}
+		seq := seql.Elts[len(seql.Elts)-1].(*ast.StructLit)
+		seq.Elts = append(seq.Elts, &ast.Field{Label: ast.NewIdent("lens"), Value: lens})
+		versions = append(versions, v)
+	}
+
+	return f, versions, nil
+}
+
 // InsertSchemaNodeAs inserts the provided schema ast.Expr into the provided
 // lineage ast.Node at the position corresponding to the provided version. The
 // provided schema will either replace an existing schema, or be appended to the
@@ -119,7 +178,8 @@ seqs: [
 // If the provided schema is backwards compatible with the latest schema in the
 // lineage, the new schema will be appended to the latest sequence (minor
 // version bump). Otherwise, a new sequence will be created with the provided
-// schema as its only element (major version bump).
+// schema as its only element (major version bump), along with a lens skeleton
+// as generated by [LensSkeleton].
 func Append(lin thema.Lineage, sch cue.Value) (ast.Node, error) {
 	linf := astutil.Format(lin.UnwrapCUE()).(*ast.File)
 	schnode := astutil.ToExpr(astutil.Format(sch))
@@ -148,8 +208,13 @@ func Append(lin thema.Lineage, sch cue.Value) (ast.Node, error) {
 			return nil, fmt.Errorf("could not find seqs list in lineage input")
 		}
 
-		seql.Elts = append(seql.Elts, newSequenceNode(schnode))
-		// TODO add boilerplate lenses, etc.
+		lens, err := LensSkeleton(lsch.UnwrapCUE(), sch, lv)
+		if err != nil {
+			return nil, err
+		}
+		seq := newSequenceNode(schnode)
+		seq.Elts = append(seq.Elts, &ast.Field{Label: ast.NewIdent("lens"), Value: lens})
+		seql.Elts = append(seql.Elts, seq)
 	}
 
 	return linf, nil
@@ -160,10 +225,16 @@ func newSequenceNode(sch ast.Expr) *ast.StructLit {
 		sch = ast.NewStruct() // use empty struct
 	}
 
-	return ast.NewStruct(&ast.Field{
+	// Positions make the formatter put the new sequence, and the end of its
+	// schemas list, on their own lines
+	schl := ast.NewList(sch)
+	schl.Rbrack = token.Newline.Pos()
+	seq := ast.NewStruct(&ast.Field{
 		Label: ast.NewString("schemas"),
-		Value: ast.NewList(sch),
+		Value: schl,
 	})
+	ast.SetRelPos(seq, token.Newline)
+	return seq
 }
 
 func versionComment(v thema.SyntacticVersion) *ast.CommentGroup {
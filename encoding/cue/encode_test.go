@@ -1,11 +1,17 @@
 package cue
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
 	"github.com/grafana/thema"
 	"github.com/grafana/thema/exemplars"
+	"github.com/grafana/thema/internal/astutil"
 )
 
 var ctx = cuecontext.New()
@@ -39,6 +45,68 @@ foo?: int
 	if err != nil {
 		t.Fatal(err)
 	}
-	_ = f
-	// fmt.Println(string(astutil.FmtNodeP(f)))
+
+	// The schema is incompatible, so should start a new sequence with a lens
+	seqs := astutil.FindSeqs(f)
+	if len(seqs.Elts) != 2 {
+		t.Fatalf("expected a new sequence to be appended, got %d sequences", len(seqs.Elts))
+	}
+	if _, err = astutil.GetFieldByLabel(seqs.Elts[1], "lens"); err != nil {
+		t.Fatalf("expected lens skeleton in new sequence:\n%s", astutil.FmtNodeP(f))
+	}
+}
+
+func TestNewLineageFromHistory(t *testing.T) {
+	schstrs := []string{
+		`{ a: string }`,
+		`{ a: string, b?: int }`,
+		`{ a: int, b?: int }`,
+		`{ a: int, b?: int, c?: bool }`,
+	}
+	schs := make([]cue.Value, len(schstrs))
+	for i, s := range schstrs {
+		schs[i] = ctx.CompileString(s)
+	}
+
+	f, versions, err := NewLineageFromHistory(schs, "history", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []thema.SyntacticVersion{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	if fmt.Sprint(versions) != fmt.Sprint(expected) {
+		t.Fatalf("expected versions %v, got %v", expected, versions)
+	}
+
+	src, err := astutil.FmtNode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range expected {
+		if !strings.Contains(string(src), "// v"+v.String()) {
+			t.Errorf("expected schema %s to be marked with its version:\n%s", v, src)
+		}
+	}
+
+	// Drop the import and embedded thema.#Lineage, which is instead unified in
+	// from the runtime
+	var decls []ast.Decl
+	for _, d := range f.Decls {
+		if _, ok := d.(*ast.Field); ok {
+			decls = append(decls, d)
+		}
+	}
+	f.Decls = decls
+	b, err := astutil.FmtNode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileBytes(b))
+	lin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatalf("generated lineage does not bind:\n%s\n%s", b, errors.Details(err, nil))
+	}
+	if lv := thema.LatestVersion(lin); lv != expected[len(expected)-1] {
+		t.Fatalf("expected latest version %s, got %s", expected[len(expected)-1], lv)
+	}
 }
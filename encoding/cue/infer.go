@@ -0,0 +1,192 @@
+package cue
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+	"github.com/grafana/thema/internal/astutil"
+)
+
+// InferSchema infers a schema from a corpus of sample instances, such as those
+// decoded from JSON or YAML files. Each sample must be a struct. The inferred
+// schema, suitable for use as the initial schema in a new lineage, accepts all
+// of the samples.
+//
+// The fields of all samples are merged, and fields that are absent from any
+// sample, or from any struct at the same position within a sample (including
+// the elements of a list), are optional. Fields whose values vary in kind are
+// disjunctions of each observed kind.
+//
+// Null is not permitted in schemas, as optionality is expressed with ?
+// instead. Fields observed as null are therefore optional, and null is not
+// among their accepted kinds; a field only ever observed as null may be any
+// value. Samples containing null fields validate once those fields are
+// omitted.
+//
+// Fields for which at least two, and no more than maxEnum, distinct string
+// values are observed, with at least one value observed more than once, are
+// inferred to be an enum of those values. A single repeated value is not
+// treated as an enum, as it would constrain the field to a constant. A maxEnum
+// of zero disables enum inference.
+func InferSchema(samples []cue.Value, maxEnum int) (ast.Expr, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("must provide at least one sample from which to infer a schema")
+	}
+
+	root := &inferred{}
+	for _, v := range samples {
+		if k := v.IncompleteKind(); k != cue.StructKind {
+			return nil, fmt.Errorf("%s: samples must be structs, got %s", v.Pos(), k)
+		}
+		if err := root.observe(v, maxEnum); err != nil {
+			return nil, err
+		}
+	}
+	return root.expr(maxEnum), nil
+}
+
+// inferred accumulates observations of the values at a single position across
+// samples.
+type inferred struct {
+	// union of the kinds of all observed values
+	kinds cue.Kind
+
+	// number of observed strings, and their distinct values in the order first
+	// seen, up to one more than the enum limit
+	nstr int
+	strs []string
+
+	// number of observed structs, and the union of their fields, in the order
+	// first seen
+	nstruct int
+	fields  map[string]*inferred
+	order   []string
+	// number of structs in which each field was present
+	present map[string]int
+
+	// union of all observed list elements
+	elems *inferred
+}
+
+func (in *inferred) observe(v cue.Value, maxEnum int) error {
+	k := v.IncompleteKind()
+	in.kinds |= k
+
+	switch k {
+	case cue.StringKind:
+		s, err := v.String()
+		if err != nil {
+			return err
+		}
+		in.nstr++
+		if len(in.strs) <= maxEnum && !containsStr(in.strs, s) {
+			in.strs = append(in.strs, s)
+		}
+	case cue.StructKind:
+		if in.fields == nil {
+			in.fields, in.present = make(map[string]*inferred), make(map[string]int)
+		}
+		in.nstruct++
+
+		iter, err := v.Fields()
+		if err != nil {
+			return err
+		}
+		for iter.Next() {
+			name := unquote(iter.Selector().String())
+			f, has := in.fields[name]
+			if !has {
+				f = &inferred{}
+				in.fields[name] = f
+				in.order = append(in.order, name)
+			}
+			in.present[name]++
+			if err = f.observe(iter.Value(), maxEnum); err != nil {
+				return err
+			}
+		}
+	case cue.ListKind:
+		if in.elems == nil {
+			in.elems = &inferred{}
+		}
+		iter, err := v.List()
+		if err != nil {
+			return err
+		}
+		for iter.Next() {
+			if err = in.elems.observe(iter.Value(), maxEnum); err != nil {
+				return err
+			}
+		}
+	case cue.NullKind, cue.BoolKind, cue.IntKind, cue.FloatKind, cue.BytesKind:
+	default:
+		return fmt.Errorf("%s: cannot infer schema from value of kind %s", v.Pos(), k)
+	}
+	return nil
+}
+
+func (in *inferred) expr(maxEnum int) ast.Expr {
+	var disj []ast.Expr
+	if in.kinds&cue.StructKind != 0 {
+		st := ast.NewStruct()
+		for _, name := range in.order {
+			f := in.fields[name]
+			field := &ast.Field{
+				Label: astutil.NewLabel(name),
+				Value: f.expr(maxEnum),
+			}
+			if in.present[name] < in.nstruct || f.kinds&cue.NullKind != 0 {
+				field.Optional = token.Blank.Pos()
+			}
+			st.Elts = append(st.Elts, field)
+		}
+		disj = append(disj, st)
+	}
+	if in.kinds&cue.ListKind != 0 {
+		// Lists that were only ever observed empty may contain anything
+		var elem ast.Expr = ast.NewIdent("_")
+		if in.elems.kinds != 0 {
+			elem = in.elems.expr(maxEnum)
+		}
+		disj = append(disj, ast.NewList(&ast.Ellipsis{Type: elem}))
+	}
+	if in.kinds&cue.StringKind != 0 {
+		if maxEnum > 0 && len(in.strs) >= 2 && len(in.strs) <= maxEnum && in.nstr > len(in.strs) {
+			for _, s := range in.strs {
+				disj = append(disj, ast.NewString(s))
+			}
+		} else {
+			disj = append(disj, ast.NewIdent("string"))
+		}
+	}
+	if in.kinds&cue.BytesKind != 0 {
+		disj = append(disj, ast.NewIdent("bytes"))
+	}
+	switch {
+	case in.kinds&cue.NumberKind == cue.NumberKind:
+		disj = append(disj, ast.NewIdent("number"))
+	case in.kinds&cue.IntKind != 0:
+		disj = append(disj, ast.NewIdent("int"))
+	case in.kinds&cue.FloatKind != 0:
+		disj = append(disj, ast.NewIdent("float"))
+	}
+	if in.kinds&cue.BoolKind != 0 {
+		disj = append(disj, ast.NewIdent("bool"))
+	}
+	if len(disj) == 0 {
+		// Only null was observed
+		return ast.NewIdent("_")
+	}
+	return ast.NewBinExpr(token.OR, disj...)
+}
+
+func containsStr(strs []string, s string) bool {
+	for _, x := range strs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package cue
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/astutil"
+)
+
+func TestInferSchema(t *testing.T) {
+	samplestrs := []string{
+		`{
+			kind: "a", same: "k", port: 80, ratio: 0.5, on: true, tags: ["x"], "a-b": "c", string: "s",
+			nested: {x: 1, y: "y"},
+			items: [{id: 1}, {id: 2, note: "n"}],
+			empty: []
+		}`,
+		`{ kind: "b", same: "k", port: 443, ratio: 1, on: false, tags: [], "a-b": "d", string: "t", nested: {x: 2}, empty: [], maybe: null, never: null }`,
+		`{ kind: "a", same: "k", port: 8080, ratio: 2, on: true, "a-b": "e", string: "u", nested: {x: 3}, empty: [], maybe: "m", never: null }`,
+	}
+	samples := make([]cue.Value, len(samplestrs))
+	for i, s := range samplestrs {
+		samples[i] = ctx.CompileString(s)
+	}
+
+	expr, err := InferSchema(samples, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := astutil.FmtNode(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linstr := fmt.Sprintf(`
+name: "inferred"
+seqs: [
+	{
+		schemas: [%s]
+	},
+]
+`, b)
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(linstr))
+	lin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatalf("inferred schema does not bind:\n%s\n%s", b, errors.Details(err, nil))
+	}
+
+	sch := thema.SchemaP(lin, thema.SV(0, 0))
+	for i, s := range samplestrs {
+		// Null fields are inferred as optional, so samples are valid once they
+		// are omitted
+		s = strings.NewReplacer(", maybe: null", "", ", never: null", "").Replace(s)
+		if _, err := sch.Validate(ctx.CompileString(s)); err != nil {
+			t.Errorf("sample %d does not validate against inferred schema:\n%s\n%s", i, b, errors.Details(err, nil))
+		}
+	}
+
+	tt := map[string]struct {
+		data  string
+		valid bool
+	}{
+		"omitOptional":  {`{ kind: "b", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [] }`, true},
+		"enumViolation": {`{ kind: "c", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [] }`, false},
+		"singleValue":   {`{ kind: "b", same: "z", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [] }`, true},
+		"wrongKind":     {`{ kind: "b", port: "1", ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [] }`, false},
+		"unknownField":  {`{ kind: "b", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [], other: 1 }`, false},
+		"maybeString":   {`{ kind: "b", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [], maybe: "m" }`, true},
+		"maybeNull":     {`{ kind: "b", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [], maybe: null }`, false},
+		"neverAnything": {`{ kind: "b", port: 1, ratio: 1, on: true, "a-b": "x", string: "s", nested: {x: 1}, empty: [], never: 1 }`, true},
+	}
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := sch.Validate(ctx.CompileString(tc.data))
+			if tc.valid && err != nil {
+				t.Fatalf("expected data to be valid:\n%s\n%s", b, errors.Details(err, nil))
+			}
+			if !tc.valid && err == nil {
+				t.Fatalf("expected data to be invalid:\n%s", b)
+			}
+		})
+	}
+
+	if _, err = InferSchema([]cue.Value{ctx.CompileString(`[1]`)}, 2); err == nil {
+		t.Fatal("expected error for non-struct sample")
+	}
+}
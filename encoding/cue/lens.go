@@ -0,0 +1,302 @@
+package cue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/astutil"
+)
+
+// LensSkeleton generates a lens skeleton, suitable for use as the value of the
+// lens field in a new sequence, between the schema at version fromv (the last
+// schema in its sequence) and the first schema in the following sequence.
+//
+// The skeleton contains forward and reverse lenses. In each, top-level fields
+// whose type is the same in both schemas are copied from the source schema in
+// rel, even if their optionality changed. Fields that are optional in the
+// source are copied only if present. Fields absent from the target schema get
+// a pre-filled DroppedField lacuna, and required fields without a default in
+// the target schema that cannot be copied get a placeholder value in rel,
+// along with a Placeholder lacuna. Fields whose type changed are treated as
+// both dropped and new, and are marked with a TODO comment. Nested structs are
+// not inspected; they are copied only if identical.
+//
+// Authors are expected to replace the generated mappings and lacunas with the
+// real semantic relationship between the schemas.
+func LensSkeleton(from, to cue.Value, fromv thema.SyntacticVersion) (ast.Expr, error) {
+	fromref := fmt.Sprintf("seqs[%d].schemas[%d]", fromv[0], fromv[1])
+	toref := fmt.Sprintf("seqs[%d].schemas[0]", fromv[0]+1)
+
+	fwd, err := lensDirection(from, to, fromref, toref)
+	if err != nil {
+		return nil, fmt.Errorf("error generating forward lens: %w", err)
+	}
+	rev, err := lensDirection(to, from, toref, fromref)
+	if err != nil {
+		return nil, fmt.Errorf("error generating reverse lens: %w", err)
+	}
+
+	src := fmt.Sprintf("{\nforward: %s\nreverse: %s\n}", fwd, rev)
+	expr, err := parser.ParseExpr("lens", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("%s\nerror while parsing generated lens: %w", src, err)
+	}
+	return expr, nil
+}
+
+type lensField struct {
+	name     string
+	v        cue.Value
+	optional bool
+}
+
+func schemaFields(sch cue.Value) ([]lensField, error) {
+	iter, err := sch.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []lensField
+	for iter.Next() {
+		fields = append(fields, lensField{
+			name:     iter.Selector().String(),
+			v:        iter.Value(),
+			optional: iter.IsOptional(),
+		})
+	}
+	return fields, nil
+}
+
+// lensDirection generates the CUE source for a single direction of a lens.
+func lensDirection(from, to cue.Value, fromref, toref string) (string, error) {
+	ffields, err := schemaFields(from)
+	if err != nil {
+		return "", err
+	}
+	tfields, err := schemaFields(to)
+	if err != nil {
+		return "", err
+	}
+
+	fidx := make(map[string]lensField, len(ffields))
+	for _, f := range ffields {
+		fidx[f.name] = f
+	}
+	copied := make(map[string]bool)
+
+	var rel, lacunas strings.Builder
+	for _, tf := range tfields {
+		_, hasdef := tf.v.Default()
+		needval := !tf.optional && !hasdef
+
+		ff, has := fidx[tf.name]
+		if has && sameType(ff, tf) {
+			copied[tf.name] = true
+			if !ff.optional {
+				fmt.Fprintf(&rel, "%s: %s\n", tf.name, fieldRef("from", tf.name))
+				continue
+			}
+			fmt.Fprintf(&rel, "if %s != _|_ {\n%s: %s\n}\n", fieldRef("from", tf.name), tf.name, fieldRef("from", tf.name))
+			if needval {
+				// The target field is required, so it needs a value even when
+				// the source field is absent
+				fmt.Fprintf(&rel, "if %s == _|_ {\n%s: %s\n}\n", fieldRef("from", tf.name), tf.name, placeholder(tf.v))
+				fmt.Fprintf(&lacunas, "if %s == _|_ %s,\n", fieldRef("from", tf.name), placeholderLacuna(tf.name))
+			}
+			continue
+		}
+
+		if has {
+			fmt.Fprintf(&rel, "// TODO the type of %s changed - map it from %s\n", tf.name, fieldRef("from", tf.name))
+		}
+		if !needval {
+			continue
+		}
+		fmt.Fprintf(&rel, "%s: %s\n", tf.name, placeholder(tf.v))
+		fmt.Fprintf(&lacunas, "%s,\n", placeholderLacuna(tf.name))
+	}
+
+	for _, ff := range ffields {
+		if copied[ff.name] {
+			continue
+		}
+		lac := fmt.Sprintf(`{
+	sourceFields: [{path: %q, value: %s}]
+	message: "%s is dropped"
+	type: {name: "DroppedField", id: 2}
+}`, unquote(ff.name), fieldRef("from", ff.name), unquote(ff.name))
+		if ff.optional {
+			fmt.Fprintf(&lacunas, "if %s != _|_ %s,\n", fieldRef("from", ff.name), lac)
+		} else {
+			fmt.Fprintf(&lacunas, "%s,\n", lac)
+		}
+	}
+
+	return fmt.Sprintf(`{
+	to: %s
+	from: %s
+	translated: to & rel
+	rel: {%s}
+	lacunas: [%s]
+}`, toref, fromref, block(rel.String()), block(lacunas.String())), nil
+}
+
+// block puts non-empty generated source on its own lines, so that it is
+// formatted as a multi-line struct or list.
+func block(src string) string {
+	if src == "" {
+		return ""
+	}
+	return "\n" + src
+}
+
+// placeholderLacuna returns CUE source for a Placeholder lacuna for the named
+// field in rel.
+func placeholderLacuna(name string) string {
+	return fmt.Sprintf(`{
+	targetFields: [{path: %q, value: %s}]
+	message: "%s is a placeholder value - replace with a real value before persisting"
+	type: {name: "Placeholder", id: 1}
+}`, unquote(name), fieldRef("rel", name), unquote(name))
+}
+
+// sameType reports whether two fields have the same type, such that the value
+// of one may be copied directly to the other. Their optionality is not
+// considered.
+func sameType(a, b lensField) bool {
+	as, aerr := astutil.FmtNode(astutil.Format(a.v))
+	bs, berr := astutil.FmtNode(astutil.Format(b.v))
+	if aerr == nil && berr == nil && string(as) == string(bs) {
+		return true
+	}
+	// Subsume is unreliable for disjunctions, which are at least caught by the
+	// syntactic comparison above
+	return a.v.Subsume(b.v) == nil && b.v.Subsume(a.v) == nil
+}
+
+// placeholder returns CUE source for a placeholder value accepted by the
+// provided schema field. The field's default is used if it has one. Otherwise,
+// candidate values of the field's kind are tried in turn, and the first that
+// satisfies all of the field's constraints (e.g. bounds, patterns, or required
+// fields of a struct) is used. If no candidate satisfies them, the first is
+// returned anyway, and must be corrected by hand.
+func placeholder(v cue.Value) string {
+	if d, has := v.Default(); has && d.IsConcrete() {
+		return fmt.Sprint(d)
+	}
+
+	cands := placeholderCandidates(v)
+	for _, c := range cands {
+		cv := v.Context().CompileString(c)
+		if cv.Err() == nil && v.Unify(cv).Validate(cue.Concrete(true)) == nil {
+			return c
+		}
+	}
+	return cands[0]
+}
+
+// placeholderCandidates returns CUE source for candidate placeholder values
+// for the provided schema field, in order of preference.
+func placeholderCandidates(v cue.Value) []string {
+	var cands []string
+	// Prefer the first concrete branch of a disjunction, e.g. an enum
+	if op, args := v.Expr(); op == cue.OrOp {
+		for _, arg := range args {
+			if arg.IsConcrete() {
+				cands = append(cands, fmt.Sprint(arg))
+			}
+		}
+	}
+
+	switch v.IncompleteKind() {
+	case cue.StringKind:
+		cands = append(cands, `""`, `"x"`, `"0"`)
+	case cue.IntKind, cue.FloatKind, cue.NumberKind:
+		cands = append(cands, "0", "1", "-1")
+		// Values at and either side of each bound, e.g. 6 for >5
+		for _, b := range bounds(v) {
+			for _, src := range []string{"%s", "%s + 1", "%s - 1"} {
+				if bv := v.Context().CompileString(fmt.Sprintf(src, b)); bv.Err() == nil {
+					cands = append(cands, fmt.Sprint(bv))
+				}
+			}
+		}
+	case cue.BoolKind:
+		cands = append(cands, "false", "true")
+	case cue.ListKind:
+		cands = append(cands, "[]")
+		if ev := v.LookupPath(cue.MakePath(cue.AnyIndex)); ev.Exists() {
+			cands = append(cands, fmt.Sprintf("[%s]", placeholder(ev)))
+		}
+	case cue.NullKind:
+		cands = append(cands, "null")
+	default:
+		cands = append(cands, "{}")
+		if src, ok := structPlaceholder(v); ok {
+			cands = append(cands, src)
+		}
+	}
+	return cands
+}
+
+// bounds returns CUE source for the bounds of all comparison constraints, such
+// as >5 or !=0, in the provided value.
+func bounds(v cue.Value) []string {
+	op, args := v.Expr()
+	switch op {
+	case cue.AndOp:
+		var b []string
+		for _, arg := range args {
+			b = append(b, bounds(arg)...)
+		}
+		return b
+	case cue.LessThanOp, cue.LessThanEqualOp, cue.GreaterThanOp, cue.GreaterThanEqualOp, cue.NotEqualOp:
+		if len(args) == 1 && args[0].IsConcrete() {
+			return []string{fmt.Sprint(args[0])}
+		}
+	}
+	return nil
+}
+
+// structPlaceholder returns CUE source for a struct in which each of the
+// required fields of the provided struct schema without a default has a
+// placeholder value. ok is false if the schema has no such fields.
+func structPlaceholder(v cue.Value) (src string, ok bool) {
+	fields, err := schemaFields(v)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		if _, hasdef := f.v.Default(); f.optional || hasdef {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f.name, placeholder(f.v))
+	}
+	if b.Len() == 0 {
+		return "", false
+	}
+	return "{\n" + b.String() + "}", true
+}
+
+// fieldRef returns a CUE reference to the named field within the struct
+// referenced by base.
+func fieldRef(base, name string) string {
+	if ast.IsValidIdent(name) {
+		return base + "." + name
+	}
+	return fmt.Sprintf("%s[%s]", base, name)
+}
+
+func unquote(name string) string {
+	if s, err := strconv.Unquote(name); err == nil {
+		return s
+	}
+	return name
+}
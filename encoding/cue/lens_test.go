@@ -0,0 +1,269 @@
+package cue
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/internal/astutil"
+)
+
+func TestLensSkeleton(t *testing.T) {
+	fromstr := `{
+	a: string
+	b?: int
+	c: string
+}`
+	tostr := `{
+	a: string
+	c: int
+	d: "x" | "y"
+	e: int | *1
+}`
+	from, to := ctx.CompileString(fromstr), ctx.CompileString(tostr)
+
+	lens, err := LensSkeleton(from, to, thema.SV(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := astutil.FmtNode(lens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linstr := fmt.Sprintf(`
+name: "skel"
+seqs: [
+	{
+		schemas: [%s]
+	},
+	{
+		schemas: [%s]
+		lens: %s
+	},
+]
+`, fromstr, tostr, b)
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(linstr))
+	lin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatalf("generated lens skeleton does not bind:\n%s\n%s", b, errors.Details(err, nil))
+	}
+
+	inst, err := thema.SchemaP(lin, thema.SV(0, 0)).Validate(ctx.CompileString(`{ a: "foo", b: 42, c: "bar" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tinst, lac, err := inst.TranslateE(thema.SV(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tinst.UnwrapCUE().Validate(cue.Concrete(true)); err != nil {
+		t.Fatalf("translated instance is not concrete: %s", err)
+	}
+	if a, _ := tinst.UnwrapCUE().LookupPath(cue.ParsePath("a")).String(); a != "foo" {
+		t.Errorf("expected identical field a to be copied, got %q", a)
+	}
+	if d, _ := tinst.UnwrapCUE().LookupPath(cue.ParsePath("d")).String(); d != "x" {
+		t.Errorf("expected enum placeholder for d to be its first value, got %q", d)
+	}
+
+	// c changed type, d is new and required; e has a default
+	if pl := lac.ByType(thema.LacunaPlaceholder); len(pl) != 2 {
+		t.Errorf("expected 2 placeholder lacunas, got %v", pl)
+	}
+	// b was removed, and c changed type
+	if dl := lac.ByType(thema.LacunaDroppedField); len(dl) != 2 {
+		t.Errorf("expected 2 dropped field lacunas, got %v", dl)
+	}
+
+	// The optional field's lacuna is conditional on its presence
+	inst, err = thema.SchemaP(lin, thema.SV(0, 0)).Validate(ctx.CompileString(`{ a: "foo", c: "bar" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lac, err = inst.TranslateE(thema.SV(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dl := lac.ByType(thema.LacunaDroppedField); len(dl) != 1 {
+		t.Errorf("expected 1 dropped field lacuna when optional field is absent, got %v", dl)
+	}
+}
+
+func TestLensSkeletonOptionality(t *testing.T) {
+	fromstr := `{
+	a: string
+	b?: int
+}`
+	tostr := `{
+	a: string
+	b: int
+}`
+	from, to := ctx.CompileString(fromstr), ctx.CompileString(tostr)
+
+	lens, err := LensSkeleton(from, to, thema.SV(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := astutil.FmtNode(lens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "TODO") {
+		t.Fatalf("field with unchanged type reported as changed:\n%s", b)
+	}
+
+	linstr := fmt.Sprintf(`
+name: "skel"
+seqs: [
+	{
+		schemas: [%s]
+	},
+	{
+		schemas: [%s]
+		lens: %s
+	},
+]
+`, fromstr, tostr, b)
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(linstr))
+	lin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatalf("generated lens skeleton does not bind:\n%s\n%s", b, errors.Details(err, nil))
+	}
+
+	table := map[string]struct {
+		from, to    thema.SyntacticVersion
+		in, out     string
+		placeholder int
+	}{
+		"forward present": {
+			from: thema.SV(0, 0), to: thema.SV(1, 0),
+			in:  `{ a: "foo", b: 42 }`,
+			out: `{ a: "foo", b: 42 }`,
+		},
+		"forward absent": {
+			from: thema.SV(0, 0), to: thema.SV(1, 0),
+			in:          `{ a: "foo" }`,
+			out:         `{ a: "foo", b: 0 }`,
+			placeholder: 1,
+		},
+		"reverse": {
+			from: thema.SV(1, 0), to: thema.SV(0, 0),
+			in:  `{ a: "foo", b: 42 }`,
+			out: `{ a: "foo", b: 42 }`,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			inst, err := thema.SchemaP(lin, tt.from).Validate(ctx.CompileString(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tinst, lac, err := inst.TranslateE(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := ctx.CompileString(tt.out); !want.Equals(tinst.UnwrapCUE()) {
+				t.Fatalf("unexpected translation result:\nWANT: %v\nGOT:  %v", want, tinst.UnwrapCUE())
+			}
+			if pl := lac.ByType(thema.LacunaPlaceholder); len(pl) != tt.placeholder {
+				t.Errorf("expected %d placeholder lacunas, got %v", tt.placeholder, pl)
+			}
+			if dl := lac.ByType(thema.LacunaDroppedField); len(dl) != 0 {
+				t.Errorf("expected no dropped field lacunas, got %v", dl)
+			}
+		})
+	}
+}
+
+func TestLensSkeletonPlaceholderConstraints(t *testing.T) {
+	table := map[string]struct {
+		// schema of the new required field b
+		b string
+		// expected placeholder value of b after translation
+		out string
+	}{
+		"lowerBound":        {b: `>5`, out: `6`},
+		"upperBound":        {b: `int & <0`, out: `-1`},
+		"range":             {b: `int & >=10 & <=20`, out: `10`},
+		"notEqual":          {b: `!=0`, out: `1`},
+		"floatBound":        {b: `float & >0.5`, out: `1.5`},
+		"pattern":           {b: `=~"^x"`, out: `"x"`},
+		"minRunes":          {b: `strings.MinRunes(1)`, out: `"x"`},
+		"runesRange":        {b: `string & strings.MinRunes(1) & strings.MaxRunes(3)`, out: `"x"`},
+		"enum":              {b: `"a" | "b"`, out: `"a"`},
+		"structRequired":    {b: `{ x: int & >=10, y?: string, z: string | *"q" }`, out: `{ x: 10, z: "q" }`},
+		"nestedStruct":      {b: `{ x: { y: =~"^x" } }`, out: `{ x: { y: "x" } }`},
+		"unconstrainedBool": {b: `bool`, out: `false`},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			fromstr := `{
+	a: string
+}`
+			tostr := fmt.Sprintf(`{
+	a: string
+	b: %s
+}`, tt.b)
+			imports := `import "strings"
+`
+			from, to := ctx.CompileString(fromstr), ctx.CompileString(imports+tostr)
+			if to.Err() != nil {
+				t.Fatal(to.Err())
+			}
+
+			lens, err := LensSkeleton(from, to, thema.SV(0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := astutil.FmtNode(lens)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			linstr := fmt.Sprintf(`%s
+name: "skel"
+seqs: [
+	{
+		schemas: [%s]
+	},
+	{
+		schemas: [%s]
+		lens: %s
+	},
+]
+`, imports, fromstr, tostr, b)
+			linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(linstr))
+			lin, err := thema.BindLineage(linval, rt)
+			if err != nil {
+				t.Fatalf("generated lens skeleton does not bind:\n%s\n%s", b, errors.Details(err, nil))
+			}
+
+			inst, err := thema.SchemaP(lin, thema.SV(0, 0)).Validate(ctx.CompileString(`{ a: "foo" }`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tinst, lac, err := inst.TranslateE(thema.SV(1, 0))
+			if err != nil {
+				t.Fatalf("placeholder does not satisfy constraints:\n%s\n%s", b, err)
+			}
+			got, err := tinst.UnwrapCUE().LookupPath(cue.ParsePath("b")).MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, _ := ctx.CompileString(tt.out).MarshalJSON(); string(want) != string(got) {
+				t.Fatalf("unexpected placeholder:\nWANT: %s\nGOT:  %s", want, got)
+			}
+			if pl := lac.ByType(thema.LacunaPlaceholder); len(pl) != 1 {
+				t.Errorf("expected 1 placeholder lacuna, got %v", pl)
+			}
+		})
+	}
+}
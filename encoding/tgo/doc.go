@@ -1,6 +1,6 @@
 // Package tgo provides tools for generating native Go types from Thema's
-// lineage and schema abstractions. Eventually, this package may also support
-// generating Thema from Go inputs.
+// lineage and schema abstractions, and for generating Thema schemas from Go
+// types.
 //
 // "tgo" is the package name rather than simply "go" because the latter is a
 // reserved keyword.
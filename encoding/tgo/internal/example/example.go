@@ -0,0 +1,62 @@
+// Package example contains Go types used to test generation of Thema schemas
+// from Go types.
+package example
+
+import "time"
+
+// Meta is embedded in Config.
+type Meta struct {
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+// Extra is embedded in Config by pointer.
+type Extra struct {
+	Note string `json:"note"`
+}
+
+// Config exercises the conversion rules of SchemaFromType.
+type Config struct {
+	Meta
+	*Extra
+
+	Enabled  bool              `json:"enabled"`
+	Port     int               `json:"port"`
+	Small    uint8             `json:"small"`
+	Ratio    float64           `json:"ratio"`
+	Label    string            `json:"label,omitempty"`
+	Timeout  *int32            `json:"timeout"`
+	Tags     []string          `json:"tags"`
+	Pair     [2]string         `json:"pair"`
+	Env      map[string]string `json:"env"`
+	Raw      []byte            `json:"raw"`
+	Anything interface{}       `json:"anything"`
+	Nested   struct {
+		Inner string `json:"inner"`
+	} `json:"nested"`
+	Backends []Backend `json:"backends"`
+	NoTag    string
+	String   string `json:"string"`
+
+	Ignored  string `json:"-"`
+	internal string
+}
+
+// Backend is used in a list within Config.
+type Backend struct {
+	Addr   string  `json:"addr"`
+	Weight *uint16 `json:"weight,omitempty"`
+}
+
+// Node is recursive, and cannot be converted.
+type Node struct {
+	Children []Node `json:"children"`
+}
+
+// Pipe contains a channel, and cannot be converted.
+type Pipe struct {
+	C chan int `json:"c"`
+}
+
+// Mode is not a struct, and cannot be converted.
+type Mode string
@@ -0,0 +1,307 @@
+package tgo
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/build"
+	"go/importer"
+	goparser "go/parser"
+	gotoken "go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+	tastutil "github.com/grafana/thema/internal/astutil"
+)
+
+// SchemaFromPackage loads the Go package with the provided import path or
+// directory path, resolved relative to dir, and converts the named struct type
+// declared within it into a CUE schema, as with [SchemaFromType].
+func SchemaFromPackage(dir, path, typename string) (ast.Expr, error) {
+	var bpkg *build.Package
+	var err error
+	if filepath.IsAbs(path) {
+		bpkg, err = build.ImportDir(path, 0)
+	} else {
+		bpkg, err = build.Import(path, dir, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading Go package %q: %w", path, err)
+	}
+
+	fset := gotoken.NewFileSet()
+	files := make([]*goast.File, 0, len(bpkg.GoFiles))
+	for _, name := range bpkg.GoFiles {
+		f, err := goparser.ParseFile(fset, filepath.Join(bpkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("error loading Go package %q: %w", path, err)
+		}
+		files = append(files, f)
+	}
+
+	// Type check dependencies from source, rather than relying on compiler
+	// export data, which varies in format across Go toolchain versions
+	cfg := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := cfg.Check(bpkg.ImportPath, fset, files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error loading Go package %q: %w", path, err)
+	}
+
+	obj := pkg.Scope().Lookup(typename)
+	if obj == nil {
+		return nil, fmt.Errorf("no type %q declared in Go package %s", typename, pkg.Path())
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s.%s is not a type", pkg.Path(), typename)
+	}
+	return SchemaFromType(tn.Type())
+}
+
+// SchemaFromType converts the provided Go struct type into a CUE schema,
+// suitable for use as the initial schema in a new lineage. The schema is
+// guaranteed to be assignable to the Go type, per [thema.AssignableTo].
+//
+// The schema follows the behavior of encoding/json:
+//
+//   - Fields are named according to their json struct tags, if any, and
+//     unexported fields and fields tagged "-" are omitted.
+//   - Fields tagged omitempty are optional.
+//   - Fields of embedded structs are promoted into the embedding struct.
+//
+// Thema schemas may not contain null, so pointer fields, which encoding/json
+// writes as null when nil, are also optional. Fields of embedded struct
+// pointers are likewise optional.
+//
+// Go integer types correspond to CUE's sized integer types (e.g. int32), with
+// int and uint taken as int64 and uint64; floats correspond to float; []byte
+// corresponds to bytes; time.Time corresponds to string; and interfaces
+// correspond to top (_). Maps must have string keys. Channel, function,
+// complex, and recursive types are not supported.
+func SchemaFromType(t types.Type) (ast.Expr, error) {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("must provide struct-kinded type, got %s", t)
+	}
+
+	c := &goConverter{seen: make(map[*types.Named]bool)}
+	return c.expr(t)
+}
+
+var errPointerDepth = errors.New("more than one level of pointer indirection is not supported")
+
+type goConverter struct {
+	// named types currently being converted, to detect recursion
+	seen map[*types.Named]bool
+}
+
+func (c *goConverter) expr(t types.Type) (ast.Expr, error) {
+	switch x := t.(type) {
+	case *types.Named:
+		if obj := x.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return ast.NewIdent("string"), nil
+		}
+		if c.seen[x] {
+			return nil, fmt.Errorf("recursive type %s is not supported", x)
+		}
+		c.seen[x] = true
+		defer delete(c.seen, x)
+		return c.expr(x.Underlying())
+	case *types.Basic:
+		return basicExpr(x)
+	case *types.Pointer:
+		if _, ok := x.Elem().(*types.Pointer); ok {
+			return nil, errPointerDepth
+		}
+		// Nil pointers are null, which has no Thema representation; only
+		// pointer struct fields, which may be made optional, can express it
+		return c.expr(x.Elem())
+	case *types.Slice:
+		if b, ok := x.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return ast.NewIdent("bytes"), nil
+		}
+		elem, err := c.expr(x.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewList(&ast.Ellipsis{Type: elem}), nil
+	case *types.Array:
+		elems := make([]ast.Expr, x.Len())
+		for i := range elems {
+			elem, err := c.expr(x.Elem())
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return ast.NewList(elems...), nil
+	case *types.Map:
+		if k, ok := x.Key().Underlying().(*types.Basic); !ok || k.Kind() != types.String {
+			return nil, fmt.Errorf("map key type must be string, got %s", x.Key())
+		}
+		elem, err := c.expr(x.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewStruct(&ast.Field{
+			Label: ast.NewList(ast.NewIdent("string")),
+			Value: elem,
+		}), nil
+	case *types.Interface:
+		return ast.NewIdent("_"), nil
+	case *types.Struct:
+		return c.structExpr(x)
+	default:
+		return nil, fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+func basicExpr(b *types.Basic) (ast.Expr, error) {
+	switch b.Kind() {
+	case types.Bool:
+		return ast.NewIdent("bool"), nil
+	case types.String:
+		return ast.NewIdent("string"), nil
+	case types.Int, types.Int64:
+		return ast.NewIdent("int64"), nil
+	case types.Int8:
+		return ast.NewIdent("int8"), nil
+	case types.Int16:
+		return ast.NewIdent("int16"), nil
+	case types.Int32:
+		return ast.NewIdent("int32"), nil
+	case types.Uint, types.Uint64, types.Uintptr:
+		return ast.NewIdent("uint64"), nil
+	case types.Uint8:
+		return ast.NewIdent("uint8"), nil
+	case types.Uint16:
+		return ast.NewIdent("uint16"), nil
+	case types.Uint32:
+		return ast.NewIdent("uint32"), nil
+	case types.Float32, types.Float64:
+		return ast.NewIdent("float"), nil
+	default:
+		return nil, fmt.Errorf("basic type %s is not supported", b)
+	}
+}
+
+// goField is a struct field as encoding/json sees it, after promotion of
+// embedded struct fields.
+type goField struct {
+	name     string
+	typ      types.Type
+	optional bool
+	// depth of embedding at which the field was found
+	depth int
+}
+
+func (c *goConverter) structExpr(st *types.Struct) (ast.Expr, error) {
+	fields, err := c.collectFields(st, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// As with encoding/json, among fields with the same name, the shallowest
+	// wins, and a tie at the shallowest depth excludes all of them.
+	mindepth := make(map[string]int)
+	count := make(map[string]int)
+	for _, f := range fields {
+		if d, has := mindepth[f.name]; !has || f.depth < d {
+			mindepth[f.name], count[f.name] = f.depth, 0
+		}
+		if f.depth == mindepth[f.name] {
+			count[f.name]++
+		}
+	}
+
+	lit := ast.NewStruct()
+	for _, f := range fields {
+		if f.depth != mindepth[f.name] || count[f.name] > 1 {
+			continue
+		}
+
+		typ, optional := f.typ, f.optional
+		if p, ok := typ.(*types.Pointer); ok {
+			typ, optional = p.Elem(), true
+		}
+		if _, ok := typ.(*types.Pointer); ok {
+			return nil, fmt.Errorf("field %s: %w", f.name, errPointerDepth)
+		}
+
+		v, err := c.expr(typ)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+		field := &ast.Field{Label: tastutil.NewLabel(f.name), Value: v}
+		if optional {
+			field.Optional = token.Blank.Pos()
+		}
+		lit.Elts = append(lit.Elts, field)
+	}
+	return lit, nil
+}
+
+func (c *goConverter) collectFields(st *types.Struct, depth int, optional bool) ([]goField, error) {
+	var fields []goField
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		name, opts, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if f.Embedded() && name == "" {
+			ft, embopt := f.Type(), optional
+			if p, ok := ft.(*types.Pointer); ok {
+				ft, embopt = p.Elem(), true
+			}
+			if est, ok := ft.Underlying().(*types.Struct); ok {
+				named, _ := ft.(*types.Named)
+				if named != nil {
+					if c.seen[named] {
+						return nil, fmt.Errorf("recursive type %s is not supported", named)
+					}
+					c.seen[named] = true
+				}
+				efields, err := c.collectFields(est, depth+1, embopt)
+				if named != nil {
+					delete(c.seen, named)
+				}
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, efields...)
+				continue
+			}
+		}
+
+		if !f.Exported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name()
+		}
+		fields = append(fields, goField{
+			name:     name,
+			typ:      f.Type(),
+			optional: optional || hasOption(opts, "omitempty"),
+			depth:    depth,
+		})
+	}
+	return fields, nil
+}
+
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
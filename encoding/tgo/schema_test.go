@@ -0,0 +1,59 @@
+package tgo
+
+import (
+	"fmt"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/encoding/tgo/internal/example"
+	"github.com/grafana/thema/internal/astutil"
+)
+
+func TestSchemaFromPackage(t *testing.T) {
+	expr, err := SchemaFromPackage(".", "./internal/example", "Config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := astutil.FmtNode(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := thema.NewRuntime(cuecontext.New())
+	linstr := fmt.Sprintf(`
+name: "config"
+seqs: [
+	{
+		schemas: [%s]
+	},
+]
+`, b)
+	linval := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(linstr))
+	lin, err := thema.BindLineage(linval, rt)
+	if err != nil {
+		t.Fatalf("generated schema does not bind:\n%s\n%s", b, errors.Details(err, nil))
+	}
+
+	if err = thema.AssignableTo(thema.SchemaP(lin, thema.SV(0, 0)), &example.Config{}); err != nil {
+		t.Fatalf("generated schema is not assignable to source type:\n%s\n%s", b, err)
+	}
+}
+
+func TestSchemaFromPackageErrors(t *testing.T) {
+	tt := map[string]string{
+		"missing":   "NoSuchType",
+		"nonstruct": "Mode",
+		"recursive": "Node",
+		"chan":      "Pipe",
+	}
+	for name, typ := range tt {
+		t.Run(name, func(t *testing.T) {
+			if _, err := SchemaFromPackage(".", "./internal/example", typ); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,349 @@
+// Package typescript provides tools for generating TypeScript types from Thema
+// schemas.
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"cuelang.org/go/cue"
+	"github.com/grafana/thema"
+)
+
+// TypeConfig governs the behavior of [GenerateTypes].
+type TypeConfig struct {
+	// RootName is the name of the generated interface corresponding to the
+	// schema as a whole. If empty, the lineage name is used, with its first
+	// letter capitalized.
+	RootName string
+
+	// NoDefaults disables generation of the constant containing the
+	// schema-specified default values for the root interface's fields.
+	NoDefaults bool
+}
+
+// GenerateTypes generates TypeScript types from the provided Thema schema.
+//
+// The schema as a whole is represented as an exported interface. Optional
+// fields are marked optional, disjunctions become union types, and
+// disjunctions of concrete values (enums) become unions of literal types.
+//
+// Unless disabled in the config, an exported constant named "default<RootName>"
+// is also generated, containing the schema-specified default values for each
+// field of the root interface that has one.
+func GenerateTypes(sch thema.Schema, cfg *TypeConfig) ([]byte, error) {
+	if cfg == nil {
+		cfg = new(TypeConfig)
+	}
+	name := cfg.RootName
+	if name == "" {
+		name = exportedName(sch.Lineage().Name())
+	}
+
+	v := sch.UnwrapCUE()
+	if v.IncompleteKind() != cue.StructKind {
+		return nil, fmt.Errorf("schema must be struct-kinded to generate TypeScript, got %s", v.IncompleteKind())
+	}
+
+	g := &tsgen{}
+	fmt.Fprintf(&g.buf, "export interface %s ", name)
+	if err := g.structType(v, 0); err != nil {
+		return nil, err
+	}
+	g.buf.WriteString("\n")
+
+	if !cfg.NoDefaults {
+		if err := g.defaults(v, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return g.buf.Bytes(), nil
+}
+
+type tsgen struct {
+	buf bytes.Buffer
+}
+
+// tsType writes the TypeScript type expression corresponding to the provided
+// CUE value.
+func (g *tsgen) tsType(v cue.Value, depth int) error {
+	op, args := v.Expr()
+	if op == cue.OrOp {
+		return g.union(args, depth)
+	}
+
+	if v.IsConcrete() && v.IncompleteKind()&(cue.StringKind|cue.NumberKind|cue.BoolKind|cue.NullKind) != 0 {
+		return g.literal(v)
+	}
+
+	switch ik := v.IncompleteKind(); ik {
+	case cue.StructKind:
+		return g.structType(v, depth)
+	case cue.ListKind:
+		elem := v.LookupPath(cue.MakePath(cue.AnyIndex))
+		if !elem.Exists() {
+			g.buf.WriteString("unknown[]")
+			return nil
+		}
+		eop, _ := elem.Expr()
+		if eop == cue.OrOp {
+			g.buf.WriteString("Array<")
+			if err := g.tsType(elem, depth); err != nil {
+				return err
+			}
+			g.buf.WriteString(">")
+			return nil
+		}
+		if err := g.tsType(elem, depth); err != nil {
+			return err
+		}
+		g.buf.WriteString("[]")
+		return nil
+	default:
+		g.buf.WriteString(kindType(ik))
+		return nil
+	}
+}
+
+func (g *tsgen) union(args []cue.Value, depth int) error {
+	for i, arg := range args {
+		if i > 0 {
+			g.buf.WriteString(" | ")
+		}
+		if err := g.tsType(arg, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *tsgen) literal(v cue.Value) error {
+	var x interface{}
+	if err := v.Decode(&x); err != nil {
+		return err
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return err
+	}
+	g.buf.Write(b)
+	return nil
+}
+
+func (g *tsgen) structType(v cue.Value, depth int) error {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return err
+	}
+
+	var has bool
+	for iter.Next() {
+		if !has {
+			g.buf.WriteString("{\n")
+			has = true
+		}
+		indent(&g.buf, depth+1)
+		g.buf.WriteString(fieldName(iter.Selector().String()))
+		if iter.IsOptional() {
+			g.buf.WriteString("?")
+		}
+		g.buf.WriteString(": ")
+		if err = g.tsType(iter.Value(), depth+1); err != nil {
+			return fmt.Errorf("%s: %w", iter.Selector(), err)
+		}
+		g.buf.WriteString(";\n")
+	}
+
+	if has {
+		indent(&g.buf, depth)
+		g.buf.WriteString("}")
+		return nil
+	}
+
+	// No fields, so either a map type or an empty struct
+	g.buf.WriteString("Record<string, ")
+	if elem := v.LookupPath(cue.MakePath(cue.AnyString)); elem.Exists() {
+		if err = g.tsType(elem, depth); err != nil {
+			return err
+		}
+	} else {
+		g.buf.WriteString("unknown")
+	}
+	g.buf.WriteString(">")
+	return nil
+}
+
+// defaults writes a constant containing the defaults for all the fields of the
+// provided struct value that have one, including those of nested structs.
+func (g *tsgen) defaults(v cue.Value, name string) error {
+	vals, err := defaultValues(v)
+	if err != nil {
+		return err
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+
+	// Partial only makes the root's fields optional, which is not enough to
+	// allow the required fields of nested structs to be omitted.
+	partial := "Partial"
+	for _, x := range vals {
+		if _, is := x.(map[string]interface{}); is {
+			partial = "DeepPartial"
+			g.buf.WriteString("\ntype DeepPartial<T> = {\n")
+			indent(&g.buf, 1)
+			g.buf.WriteString("[P in keyof T]?: T[P] extends object ? DeepPartial<T[P]> : T[P];\n")
+			g.buf.WriteString("};\n")
+			break
+		}
+	}
+
+	fmt.Fprintf(&g.buf, "\nexport const default%s: %s<%s> = ", name, partial, name)
+	if err = g.defaultsObject(vals, 0); err != nil {
+		return err
+	}
+	g.buf.WriteString(";\n")
+	return nil
+}
+
+// defaultValues returns the defaults for all the fields of the provided struct
+// value that have one, keyed by field selector. The defaults of the fields of
+// nested structs without a default of their own are returned as a nested map.
+func defaultValues(v cue.Value) (map[string]interface{}, error) {
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make(map[string]interface{})
+	for iter.Next() {
+		fv := iter.Value()
+		d, has := fv.Default()
+		if !has || !d.IsConcrete() {
+			if fv.IncompleteKind() != cue.StructKind {
+				continue
+			}
+			nested, err := defaultValues(fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", iter.Selector(), err)
+			}
+			if len(nested) > 0 {
+				vals[iter.Selector().String()] = nested
+			}
+			continue
+		}
+		// Open lists have an implicit default of the empty list, which is
+		// not worth reporting unless it was explicitly marked as the default.
+		if op, _ := fv.Expr(); fv.IncompleteKind() == cue.ListKind && op != cue.OrOp {
+			if l, _ := d.Len().Int64(); l == 0 {
+				continue
+			}
+		}
+
+		var x interface{}
+		if err = d.Decode(&x); err != nil {
+			return nil, fmt.Errorf("%s: %w", iter.Selector(), err)
+		}
+		vals[iter.Selector().String()] = x
+	}
+	return vals, nil
+}
+
+// defaultsObject writes an object literal containing the provided defaults, as
+// returned from defaultValues.
+func (g *tsgen) defaultsObject(vals map[string]interface{}, depth int) error {
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	g.buf.WriteString("{\n")
+	for _, k := range keys {
+		indent(&g.buf, depth+1)
+		fmt.Fprintf(&g.buf, "%s: ", fieldName(k))
+		if nested, is := vals[k].(map[string]interface{}); is {
+			if err := g.defaultsObject(nested, depth+1); err != nil {
+				return err
+			}
+		} else {
+			b, err := json.Marshal(vals[k])
+			if err != nil {
+				return err
+			}
+			g.buf.Write(b)
+		}
+		g.buf.WriteString(",\n")
+	}
+	indent(&g.buf, depth)
+	g.buf.WriteString("}")
+	return nil
+}
+
+func kindType(k cue.Kind) string {
+	var types []string
+	for _, kt := range []struct {
+		k cue.Kind
+		t string
+	}{
+		{cue.NullKind, "null"},
+		{cue.BoolKind, "boolean"},
+		{cue.NumberKind, "number"},
+		{cue.StringKind, "string"},
+		{cue.BytesKind, "string"},
+		{cue.ListKind, "unknown[]"},
+		{cue.StructKind, "Record<string, unknown>"},
+	} {
+		if k&kt.k != 0 {
+			types = append(types, kt.t)
+		}
+	}
+
+	if len(types) == 0 || k == cue.TopKind {
+		return "unknown"
+	}
+	return strings.Join(types, " | ")
+}
+
+func fieldName(s string) string {
+	if isIdent(s) {
+		return s
+	}
+	b, _ := json.Marshal(strings.Trim(s, `"`))
+	return string(b)
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func exportedName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$') {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func indent(buf *bytes.Buffer, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+}
@@ -0,0 +1,179 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"github.com/grafana/thema"
+	"github.com/grafana/thema/exemplars"
+)
+
+func TestGenerateTypesExpand(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := exemplars.ExpandLineage(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateTypes(thema.SchemaP(lin, thema.SV(0, 3)), nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	expect := `export interface Expand {
+  init: string;
+  optional?: number;
+  withDefault?: "foo" | "bar" | "baz";
+}
+
+export const defaultExpand: Partial<Expand> = {
+  withDefault: "foo",
+};
+`
+	if string(b) != expect {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", b, expect)
+	}
+}
+
+var tslinstr = `
+name: "ts-kitchen"
+seqs: [{
+	schemas: [{
+		title: string
+		count: int32 | *3
+		opt?: string | int
+		nul: null | string
+		tags: [...string]
+		dtags: *["a"] | [...string]
+		nested: {
+			a: bool
+			b?: [...{x: number}]
+		}
+		m: [string]: bool
+		"weird-name": _
+	}]
+}]
+`
+
+func TestGenerateTypesKinds(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(tslinstr))
+	lin, err := thema.BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	b, err := GenerateTypes(thema.SchemaP(lin, thema.SV(0, 0)), &TypeConfig{
+		RootName: "Kitchen",
+	})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	expect := `export interface Kitchen {
+  title: string;
+  count: number;
+  opt?: string | number;
+  nul: null | string;
+  tags: string[];
+  dtags: string[];
+  nested: {
+    a: boolean;
+    b?: {
+      x: number;
+    }[];
+  };
+  m: Record<string, boolean>;
+  "weird-name": unknown;
+}
+
+export const defaultKitchen: Partial<Kitchen> = {
+  count: 3,
+  dtags: ["a"],
+};
+`
+	if string(b) != expect {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", b, expect)
+	}
+
+	b, err = GenerateTypes(thema.SchemaP(lin, thema.SV(0, 0)), &TypeConfig{
+		NoDefaults: true,
+	})
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	if !strings.HasPrefix(string(b), "export interface TsKitchen {") {
+		t.Fatalf("unexpected root name in output:\n%s", b)
+	}
+	if strings.Contains(string(b), "export const") {
+		t.Fatalf("defaults generated despite NoDefaults:\n%s", b)
+	}
+}
+
+var tsnestedlinstr = `
+name: "nested"
+seqs: [{
+	schemas: [{
+		a: string | *"x"
+		e: {
+			f: *true | bool
+			g: string
+			h: {
+				i: int | *1
+			}
+		}
+		noDefaults: {
+			j: string
+		}
+	}]
+}]
+`
+
+func TestGenerateTypesNestedDefaults(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(tsnestedlinstr))
+	lin, err := thema.BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	b, err := GenerateTypes(thema.SchemaP(lin, thema.SV(0, 0)), nil)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	expect := `export interface Nested {
+  a: string;
+  e: {
+    f: boolean;
+    g: string;
+    h: {
+      i: number;
+    };
+  };
+  noDefaults: {
+    j: string;
+  };
+}
+
+type DeepPartial<T> = {
+  [P in keyof T]?: T[P] extends object ? DeepPartial<T[P]> : T[P];
+};
+
+export const defaultNested: DeepPartial<Nested> = {
+  a: "x",
+  e: {
+    f: true,
+    h: {
+      i: 1,
+    },
+  },
+};
+`
+	if string(b) != expect {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", b, expect)
+	}
+}
@@ -0,0 +1,191 @@
+package thema
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	terrors "github.com/grafana/thema/errors"
+)
+
+// An Envelope describes how a serialized instance declares the lineage and
+// schema version it is an instance of, making the instance self-describing.
+//
+// The declaration, or header, is a struct with two string fields:
+//
+//	{
+//		"lineage": "ship",
+//		"version": "1.0"
+//	}
+//
+// If Path is empty, the envelope is a wrapper object: the header fields sit
+// at the root of the serialized object, and the instance data is in a sibling
+// "data" field:
+//
+//	{
+//		"lineage": "ship",
+//		"version": "1.0",
+//		"data": { "firstfield": "foo" }
+//	}
+//
+// Otherwise, the header is stored inline with the instance data, at the
+// reserved field identified by Path (a CUE path expression):
+//
+//	// Path: "metadata.thema"
+//	{
+//		"metadata": {
+//			"thema": { "lineage": "ship", "version": "1.0" }
+//		},
+//		"firstfield": "foo"
+//	}
+//
+// The reserved field is removed from the data before it is validated, so
+// schemas need not declare it. (Any struct left empty by its removal, such as
+// "metadata" above, is retained.)
+type Envelope struct {
+	// Path is the CUE path expression of the reserved field containing the
+	// header. If empty, the envelope is a wrapper object.
+	Path string
+}
+
+// EnvelopeHeader is the declaration of lineage and schema version carried by
+// an [Envelope].
+type EnvelopeHeader struct {
+	// Lineage is the name of the lineage.
+	Lineage string
+
+	// Version is the version of the schema within the lineage.
+	Version SyntacticVersion
+}
+
+const envelopeDataField = "data"
+
+func (env Envelope) headerPath() (cue.Path, error) {
+	if env.Path == "" {
+		return cue.Path{}, nil
+	}
+	p := cue.ParsePath(env.Path)
+	if p.Err() != nil {
+		return p, fmt.Errorf("%q is not a valid CUE path expression: %w", env.Path, p.Err())
+	}
+	return p, nil
+}
+
+// Open reads the header from the provided enveloped value, returning the header
+// and the instance data it wraps. Open does not validate the data.
+//
+// If the value does not contain a well-formed header, the returned error wraps
+// [terrors.ErrInvalidEnvelope].
+func (env Envelope) Open(v cue.Value) (EnvelopeHeader, cue.Value, error) {
+	var hdr EnvelopeHeader
+	p, err := env.headerPath()
+	if err != nil {
+		return hdr, cue.Value{}, err
+	}
+
+	hv := v.LookupPath(p)
+	if !hv.Exists() {
+		return hdr, cue.Value{}, fmt.Errorf("%w: no header at path %q", terrors.ErrInvalidEnvelope, env.Path)
+	}
+
+	if hdr.Lineage, err = hv.LookupPath(cue.MakePath(cue.Str("lineage"))).String(); err != nil {
+		return hdr, cue.Value{}, fmt.Errorf("%w: lineage must be a string: %s", terrors.ErrInvalidEnvelope, err)
+	}
+	vstr, err := hv.LookupPath(cue.MakePath(cue.Str("version"))).String()
+	if err != nil {
+		return hdr, cue.Value{}, fmt.Errorf("%w: version must be a string: %s", terrors.ErrInvalidEnvelope, err)
+	}
+	if hdr.Version, err = ParseSyntacticVersion(vstr); err != nil {
+		return hdr, cue.Value{}, fmt.Errorf("%w: %s", terrors.ErrInvalidEnvelope, err)
+	}
+
+	if env.Path == "" {
+		data := v.LookupPath(cue.MakePath(cue.Str(envelopeDataField)))
+		if !data.Exists() {
+			return hdr, cue.Value{}, fmt.Errorf("%w: wrapper object has no %q field", terrors.ErrInvalidEnvelope, envelopeDataField)
+		}
+		return hdr, data, nil
+	}
+	return hdr, withoutPath(v, p.Selectors()), nil
+}
+
+// Instance opens the provided enveloped value and validates the data it wraps
+// against the schema in the lineage with the declared version. No other
+// schemas are tried.
+//
+// If the envelope is malformed or declares a different lineage, the returned
+// error wraps [terrors.ErrInvalidEnvelope]. If the lineage contains no schema
+// with the declared version, it wraps [terrors.ErrVersionNotExist]. Otherwise,
+// any error is a validation failure from [Schema.Validate].
+func (env Envelope) Instance(lin Lineage, v cue.Value) (*Instance, error) {
+	hdr, data, err := env.Open(v)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Lineage != lin.Name() {
+		return nil, fmt.Errorf("%w: declares lineage %q, expected %q", terrors.ErrInvalidEnvelope, hdr.Lineage, lin.Name())
+	}
+
+	sch, err := lin.Schema(hdr.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", terrors.ErrVersionNotExist, err)
+	}
+	return sch.Validate(data)
+}
+
+// Envelope returns the instance's data wrapped in the provided [Envelope],
+// with a header declaring the instance's lineage and schema version.
+//
+// An error is returned if the envelope's reserved field already exists in the
+// instance data with a conflicting value.
+func (i *Instance) Envelope(env Envelope) (cue.Value, error) {
+	p, err := env.headerPath()
+	if err != nil {
+		return cue.Value{}, err
+	}
+
+	rt := i.rt()
+	rt.rl()
+	defer rt.ru()
+
+	hdr := rt.Context().Encode(struct {
+		Lineage string `json:"lineage"`
+		Version string `json:"version"`
+	}{
+		Lineage: i.sch.Lineage().Name(),
+		Version: i.sch.Version().String(),
+	})
+
+	var out cue.Value
+	if env.Path == "" {
+		out = hdr.FillPath(cue.MakePath(cue.Str(envelopeDataField)), i.raw)
+	} else {
+		out = i.raw.FillPath(p, hdr)
+	}
+	if err := out.Validate(cue.Concrete(true)); err != nil {
+		return cue.Value{}, fmt.Errorf("could not envelope instance: %w", err)
+	}
+	return out, nil
+}
+
+// withoutPath returns a copy of the struct v, with the field at the path
+// identified by the provided selectors removed.
+func withoutPath(v cue.Value, sels []cue.Selector) cue.Value {
+	if len(sels) == 0 || v.IncompleteKind() != cue.StructKind {
+		return v
+	}
+
+	out := v.Context().CompileString("{}")
+	iter, _ := v.Fields(cue.Optional(true), cue.Definitions(true), cue.Hidden(true))
+	for iter.Next() {
+		sel := iter.Selector()
+		fv := iter.Value()
+		if sel.String() == sels[0].String() {
+			if len(sels) == 1 {
+				continue
+			}
+			fv = withoutPath(fv, sels[1:])
+		}
+		out = out.FillPath(cue.MakePath(sel), fv)
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package thema
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"reflect"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestEnvelope(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(placeholderlinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	ctx := rt.Context()
+
+	inst, err := SchemaP(lin, synv(1, 0)).Validate(ctx.CompileString(`{ a: "foo", b: "bar" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := map[string]struct {
+		env  Envelope
+		want string
+	}{
+		"wrapper": {
+			env:  Envelope{},
+			want: `{"lineage":"placeholder","version":"1.0","data":{"a":"foo","b":"bar"}}`,
+		},
+		"nested": {
+			env:  Envelope{Path: "meta.thema"},
+			want: `{"a":"foo","b":"bar","meta":{"thema":{"lineage":"placeholder","version":"1.0"}}}`,
+		},
+	}
+
+	for name, item := range table {
+		tt := item
+		t.Run(name, func(t *testing.T) {
+			ev, err := inst.Envelope(tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ev.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, want interface{}
+			if err = json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected enveloped instance:\nWANT: %s\nGOT:  %s", tt.want, b)
+			}
+
+			hdr, _, err := tt.env.Open(ctx.CompileBytes(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hdr.Lineage != "placeholder" || hdr.Version != synv(1, 0) {
+				t.Fatalf("unexpected envelope header %v", hdr)
+			}
+		})
+	}
+
+	oinst, err := Envelope{}.Instance(lin, ctx.CompileString(`{ lineage: "placeholder", version: "0.0", data: { a: "foo" } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oinst.Schema().Version() != synv(0, 0) {
+		t.Fatalf("expected instance of declared version 0.0, got %s", oinst.Schema().Version())
+	}
+
+	// Data valid against 1.0 must not be accepted if it declares 0.0
+	_, err = Envelope{}.Instance(lin, ctx.CompileString(`{ lineage: "placeholder", version: "0.0", data: { a: "foo", b: "bar" } }`))
+	if !stderrors.Is(err, terrors.ErrNotAnInstance) {
+		t.Fatalf("expected data invalid against declared version to fail validation, got %v", err)
+	}
+	_, err = Envelope{}.Instance(lin, ctx.CompileString(`{ lineage: "other", version: "0.0", data: { a: "foo" } }`))
+	if !stderrors.Is(err, terrors.ErrInvalidEnvelope) {
+		t.Fatalf("expected ErrInvalidEnvelope for mismatched lineage, got %v", err)
+	}
+	_, err = Envelope{}.Instance(lin, ctx.CompileString(`{ lineage: "placeholder", version: "2.0", data: { a: "foo" } }`))
+	if !stderrors.Is(err, terrors.ErrVersionNotExist) {
+		t.Fatalf("expected ErrVersionNotExist, got %v", err)
+	}
+	_, err = Envelope{Path: "meta.thema"}.Instance(lin, ctx.CompileString(`{ a: "foo" }`))
+	if !stderrors.Is(err, terrors.ErrInvalidEnvelope) {
+		t.Fatalf("expected ErrInvalidEnvelope for missing header, got %v", err)
+	}
+}
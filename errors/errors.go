@@ -26,7 +26,11 @@ const (
 	ExcessField
 )
 
-// ValidationError is a subtype of
+// ValidationError is a placeholder for structured validation errors.
+//
+// Deprecated: Schema.Validate reports structured failures as
+// thema.ValidationError. This type cannot alias it without an import cycle,
+// and is never returned by Thema.
 type ValidationError struct {
 	msg string
 }
@@ -82,7 +86,24 @@ var (
 	// given version.
 	ErrVersionNotExist = errors.New("lineage does not contain schema with version") // ErrNoSchemaWithVersion
 
+	// ErrLineageNotExist indicates that no lineage exists in a catalog with a
+	// given name.
+	ErrLineageNotExist = errors.New("catalog does not contain lineage with name")
+
 	// ErrMalformedSyntacticVersion indicates a string input of a syntactic
 	// version was malformed.
 	ErrMalformedSyntacticVersion = errors.New("not a valid syntactic version")
+
+	// ErrInvalidEnvelope indicates that data did not contain a well-formed
+	// envelope declaring its lineage and schema version.
+	ErrInvalidEnvelope = errors.New("data does not contain a valid thema envelope")
+)
+
+// Translation errors
+var (
+	// ErrTranslationFailed indicates that translating an instance from one
+	// schema version to another failed. Thema's invariants are intended to make
+	// this unreachable for valid instances of a valid lineage, so it generally
+	// indicates a bug in a lens, or in Thema itself.
+	ErrTranslationFailed = errors.New("translation failed")
 )
@@ -37,10 +37,11 @@ func All(rt *thema.Runtime) map[string]thema.Lineage {
 }
 
 var nameOpts = map[string][]thema.BindOption{
-	"defaultchange": {thema.SkipBuggyChecks()},
+	"defaultchange": {},
 	"narrowing":     {},
 	"rename":        {},
 	"expand":        {},
+	"expanddefault": {},
 	"single":        {},
 }
 
@@ -117,6 +118,11 @@ func ExpandLineage(rt *thema.Runtime, o ...thema.BindOption) (thema.Lineage, err
 	return lineageForExemplar("expand", rt, o...)
 }
 
+// ExpandDefaultLineage returns a handle for using the "expanddefault" exemplar lineage.
+func ExpandDefaultLineage(rt *thema.Runtime, o ...thema.BindOption) (thema.Lineage, error) {
+	return lineageForExemplar("expanddefault", rt, o...)
+}
+
 // SingleLineage returns a handle for using the "single" exemplar lineage.
 func SingleLineage(rt *thema.Runtime, o ...thema.BindOption) (thema.Lineage, error) {
 	return lineageForExemplar("single", rt, o...)
@@ -126,6 +132,7 @@ var _ thema.LineageFactory = NarrowingLineage
 var _ thema.LineageFactory = RenameLineage
 var _ thema.LineageFactory = DefaultChangeLineage
 var _ thema.LineageFactory = ExpandLineage
+var _ thema.LineageFactory = ExpandDefaultLineage
 var _ thema.LineageFactory = SingleLineage
 
 // Build the harness containing a single exemplar lineage.
@@ -143,7 +150,7 @@ func harnessForExemplar(name string, rt *thema.Runtime) cue.Value {
 // Build a Lineage representing a single exemplar.
 func lineageForExemplar(name string, rt *thema.Runtime, o ...thema.BindOption) (thema.Lineage, error) {
 	switch name {
-	case "defaultchange", "narrowing", "rename":
+	case "narrowing", "rename":
 		o = append(o, thema.SkipBuggyChecks())
 	}
 	return thema.BindLineage(harnessForExemplar(name, rt), rt, o...)
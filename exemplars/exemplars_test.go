@@ -47,3 +47,28 @@ func BenchmarkBindLineage(b *testing.B) {
 		})
 	}
 }
+
+func TestExpandDefaultTranslate(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lin, err := ExpandDefaultLineage(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inst, err := thema.SchemaP(lin, thema.SV(0, 0)).Validate(rt.Context().CompileString(`{ init: "hello" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tinst, _, err := inst.TranslateE(thema.SV(0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tinst.UnwrapCUE().MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"init":"hello","withDefault":"foo","count":0}`; string(got) != want {
+		t.Fatalf("unexpected translation result:\nWANT: %s\nGOT:  %s", want, got)
+	}
+}
@@ -0,0 +1,27 @@
+package exemplars
+
+import "github.com/grafana/thema"
+
+expanddefault: {
+	description: "A few schema in a single sequence, illustrating the addition of required fields with defaults, which is permitted by backwards compatibility rules because data lacking those fields is made valid by the default."
+	l:           thema.#Lineage & {
+		seqs: [
+			{
+				schemas: [
+					{// 0.0
+						init: string
+					},
+					{// 0.1
+						init:        string
+						withDefault: *"foo" | "bar"
+					},
+					{// 0.2
+						init:        string
+						withDefault: *"foo" | "bar" | "baz"
+						count:       int | *0
+					},
+				]
+			},
+		]
+	}
+}
@@ -33,10 +33,13 @@ type compatInvariantError struct {
 	detail    error
 }
 
+func (e *compatInvariantError) Unwrap() error {
+	return e.detail
+}
+
 func (e *compatInvariantError) Error() string {
 	if e.violation[0][0] == e.violation[1][0] {
-		// TODO better
-		return e.detail.Error()
+		return fmt.Sprintf("schema %s must be backwards compatible with schema %s: %s", e.violation[1], e.violation[0], e.detail)
 	}
 	return fmt.Sprintf("schema %s must be backwards incompatible with schema %s", e.violation[1], e.violation[0])
 }
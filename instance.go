@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	terrors "github.com/grafana/thema/errors"
 )
 
 // BindInstanceType produces a TypedInstance, given an Instance and a
@@ -76,8 +78,6 @@ func (i *Instance) Dehydrate() *Instance {
 
 // AsSuccessor translates the instance into the form specified by the successor
 // schema.
-//
-// TODO figure out how to represent unary vs. composite lineages here
 func (i *Instance) AsSuccessor() (*Instance, TranslationLacunas) {
 	return i.Translate(i.sch.Successor().Version())
 }
@@ -85,9 +85,14 @@ func (i *Instance) AsSuccessor() (*Instance, TranslationLacunas) {
 // AsPredecessor translates the instance into the form specified by the predecessor
 // schema.
 //
-// TODO figure out how to represent unary vs. composite lineages here
+// AsPredecessor panics if the instance's schema is the first in its lineage
+// (0.0), as it has no predecessor.
 func (i *Instance) AsPredecessor() (*Instance, TranslationLacunas) {
-	panic("TODO translation from newer to older schema is not yet implemented")
+	pred := i.sch.Predecessor()
+	if pred == nil {
+		panic(fmt.Sprintf("schema %s has no predecessor", i.sch.Version()))
+	}
+	return i.Translate(pred.Version())
 }
 
 // UnwrapCUE returns the cue.Value representing the instance's underlying data.
@@ -138,10 +143,14 @@ func (inst *TypedInstance[T]) ValueP() T {
 // the lineage author does not write it, with translation relying on simple
 // unification. Lacunas cannot be emitted from such translations.
 //
-// Forward translation across sequences (e.g. 0.0 to 1.0), and all reverse
-// translation regardless of sequence boundaries (e.g. 1.1 to either 1.0
-// or 0.0), is nontrivial and relies on explicitly defined lenses, which
-// introduce room for lacunas and author judgment.
+// Reverse translation within a sequence (e.g. 0.7 to 0.0) narrows the instance
+// to the older schema. Fields the older schema does not allow are dropped, and
+// a DroppedField lacuna is emitted describing them.
+//
+// Forward translation across sequences (e.g. 0.0 to 1.0), and reverse
+// translation across sequences (e.g. 1.1 to 0.0), is nontrivial and relies on
+// explicitly defined lenses, which introduce room for lacunas and author
+// judgment.
 //
 // Thema translation is non-invertible over instances in the general case by
 // design. That is, Thema does not guarantee that translating an instance from
@@ -149,35 +158,65 @@ func (inst *TypedInstance[T]) ValueP() T {
 // preservation can be fully achieved in a wrapping layer, so we avoid introducing
 // complexity into Thema that is not essential for all use cases.)
 //
-// NOTE reverse translation is not yet supported, and attempting it will panic.
+// Translate panics if no schema exists in the lineage with the target version,
+// or if translation fails. Prefer [Instance.TranslateE] in programs that must
+// not crash on bad input.
 //
 // TODO define this in terms of AsSuccessor and AsPredecessor, rather than those in terms of this.
 func (i *Instance) Translate(to SyntacticVersion) (*Instance, TranslationLacunas) {
-	if to.Less(i.Schema().Version()) {
-		panic(fmt.Sprintf("FIXME translation of instances from newer to older schema is not yet implemented - %s->%s was requested", i.Schema().Version(), to))
+	tinst, lac, err := i.TranslateE(to)
+	if err != nil {
+		panic(err)
 	}
+	return tinst, lac
+}
+
+// TranslateE is the same as [Instance.Translate], but returns an error instead
+// of panicking.
+//
+// If no schema exists in the lineage with the target version, the returned
+// error wraps [terrors.ErrVersionNotExist]. If translation itself fails, the
+// returned error wraps [terrors.ErrTranslationFailed].
+func (i *Instance) TranslateE(to SyntacticVersion) (*Instance, TranslationLacunas, error) {
 	newsch, err := i.Schema().Lineage().Schema(to)
 	if err != nil {
-		panic(fmt.Sprintf("no schema in lineage with version %v, cannot translate", to))
+		return nil, nil, fmt.Errorf("%w: %s", terrors.ErrVersionNotExist, to)
+	}
+	if csch, is := newsch.(*CompositeSchema); is {
+		return i.translateComposite(csch)
+	}
+
+	linst, err := i.asLinkedInstance()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s->%s: %s", terrors.ErrTranslationFailed, i.Schema().Version(), to, err)
 	}
 
 	out, err := cueArgs{
-		"linst": i.asLinkedInstance(),
+		"linst": linst,
 		"to":    to,
 	}.call("#Translate", i.rt())
 	if err != nil {
-		// This can't happen without a name change or an invariant violation
-		panic(err)
+		return nil, nil, fmt.Errorf("%w: %s->%s: %s", terrors.ErrTranslationFailed, i.Schema().Version(), to, err)
+	}
+
+	raw := out.LookupPath(cue.MakePath(cue.Str("linst"), cue.Str("inst")))
+	if !raw.Exists() {
+		return nil, nil, fmt.Errorf("%w: %s->%s: translation produced no instance", terrors.ErrTranslationFailed, i.Schema().Version(), to)
+	}
+	// A failing lens may leave the instance incomplete, rather than erroneous,
+	// so require that the result be concrete.
+	if err = raw.Validate(cue.Concrete(true)); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s->%s: %s", terrors.ErrTranslationFailed, i.Schema().Version(), to, errors.Details(err, nil))
 	}
 
 	lac := make(multiTranslationLacunas, 0)
-	out.LookupPath(cue.MakePath(cue.Str("lacunas"))).Decode(&lac)
+	out.LookupPath(cue.MakePath(cue.Str("lacunas"))).Decode(&lac) //nolint:errcheck
 
 	return &Instance{
-		raw:  out.LookupPath(cue.MakePath(cue.Str("linst"), cue.Str("inst"))),
+		raw:  raw,
 		name: i.name,
 		sch:  newsch,
-	}, lac
+	}, lac, nil
 }
 
 // TODO generic-typed Translation
@@ -196,20 +235,18 @@ func (lac multiTranslationLacunas) AsList() []Lacuna {
 	return l
 }
 
-// func TranslateComposed(lin ComposedLineage) {
+func (lac multiTranslationLacunas) ByType(t LacunaType) []Lacuna {
+	return lacunasByType(lac.AsList(), t)
+}
 
-// }
+func (lac multiTranslationLacunas) HasPlaceholder() bool {
+	return len(lac.ByType(LacunaPlaceholder)) > 0
+}
 
-func (i *Instance) asLinkedInstance() cue.Value {
-	li, err := cueArgs{
+func (i *Instance) asLinkedInstance() (cue.Value, error) {
+	return cueArgs{
 		"inst": i.raw,
 		"lin":  i.Schema().Lineage().UnwrapCUE(),
 		"v":    i.Schema().Version(),
 	}.make("#LinkedInstance", i.rt())
-	if err != nil {
-		// This can't happen without a name change or an invariant violation
-		panic(err)
-	}
-
-	return li
 }
@@ -0,0 +1,302 @@
+package thema
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+var translinstr = `
+name: "transl"
+seqs: [
+	{
+		schemas: [
+			{
+				before: string
+			},
+			{
+				before:    string
+				optional?: int
+			},
+		]
+	},
+	{
+		schemas: [
+			{
+				after:     string
+				optional?: int
+			},
+		]
+
+		lens: forward: {
+			to:         seqs[1].schemas[0]
+			from:       seqs[0].schemas[1]
+			translated: to & rel
+			rel: {
+				after: from.before
+				if from.optional != _|_ {
+					optional: from.optional
+				}
+			}
+			lacunas: []
+		}
+		lens: reverse: {
+			to:         seqs[0].schemas[1]
+			from:       seqs[1].schemas[0]
+			translated: to & rel
+			rel: {
+				before: from.after
+				if from.optional != _|_ {
+					optional: from.optional
+				}
+			}
+			lacunas: []
+		}
+	},
+]
+`
+
+func transLin(t *testing.T) Lineage {
+	t.Helper()
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(translinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	return lin
+}
+
+func TestReverseTranslate(t *testing.T) {
+	lin := transLin(t)
+	ctx := lin.Runtime().Context()
+
+	table := map[string]struct {
+		from, to SyntacticVersion
+		in, out  string
+	}{
+		"within sequence": {
+			from: synv(0, 1),
+			to:   synv(0, 0),
+			in:   `{ before: "foo", optional: 42 }`,
+			out:  `{ before: "foo" }`,
+		},
+		"across sequences": {
+			from: synv(1, 0),
+			to:   synv(0, 1),
+			in:   `{ after: "foo", optional: 42 }`,
+			out:  `{ before: "foo", optional: 42 }`,
+		},
+		"across sequences and narrowed": {
+			from: synv(1, 0),
+			to:   synv(0, 0),
+			in:   `{ after: "foo", optional: 42 }`,
+			out:  `{ before: "foo" }`,
+		},
+		"forward": {
+			from: synv(0, 0),
+			to:   synv(1, 0),
+			in:   `{ before: "foo" }`,
+			out:  `{ after: "foo" }`,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			inst, err := SchemaP(lin, tt.from).Validate(ctx.CompileString(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tinst, _ := inst.Translate(tt.to)
+			if tinst.Schema().Version() != tt.to {
+				t.Fatalf("expected translated instance to be at version %s, got %s", tt.to, tinst.Schema().Version())
+			}
+			if _, err = tinst.Schema().Validate(tinst.UnwrapCUE()); err != nil {
+				t.Fatalf("translated instance is not valid against target schema: %s", err)
+			}
+			if want := ctx.CompileString(tt.out); !want.Equals(tinst.UnwrapCUE()) {
+				t.Fatalf("unexpected translation result:\nWANT: %v\nGOT:  %v", want, tinst.UnwrapCUE())
+			}
+		})
+	}
+}
+
+func TestAsPredecessor(t *testing.T) {
+	lin := transLin(t)
+	ctx := lin.Runtime().Context()
+
+	inst, err := SchemaP(lin, synv(1, 0)).Validate(ctx.CompileString(`{ after: "foo" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinst, _ := inst.AsPredecessor()
+	if pinst.Schema().Version() != synv(0, 1) {
+		t.Fatalf("expected predecessor version 0.1, got %s", pinst.Schema().Version())
+	}
+}
+
+func TestAsPredecessorFirstSchema(t *testing.T) {
+	lin := transLin(t)
+	ctx := lin.Runtime().Context()
+
+	inst, err := SchemaP(lin, synv(0, 0)).Validate(ctx.CompileString(`{ before: "foo" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected AsPredecessor to panic on the first schema in the lineage")
+		}
+	}()
+	inst.AsPredecessor()
+}
+
+var narrowlinstr = `
+name: "narrow"
+seqs: [
+	{
+		schemas: [
+			{
+				top: string
+				nested: {
+					a: int
+					deeper: {
+						x: int
+					}
+				}
+			},
+			{
+				top: string
+				nested: {
+					a:  int
+					b?: string
+					deeper: {
+						x:  int
+						y?: int
+					}
+				}
+				extra?: int
+			},
+		]
+	},
+]
+`
+
+func TestReverseTranslateNested(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	ctx := rt.Context()
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(narrowlinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	inst, err := SchemaP(lin, synv(0, 1)).Validate(ctx.CompileString(`{
+		top: "foo"
+		nested: { a: 1, b: "bar", deeper: { x: 2, y: 3 } }
+		extra: 4
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tinst, lac := inst.Translate(synv(0, 0))
+	if _, err = tinst.Schema().Validate(tinst.UnwrapCUE()); err != nil {
+		t.Fatalf("translated instance is not valid against target schema: %s", err)
+	}
+	want := ctx.CompileString(`{ top: "foo", nested: { a: 1, deeper: { x: 2 } } }`)
+	if !want.Equals(tinst.UnwrapCUE()) {
+		t.Fatalf("unexpected translation result:\nWANT: %v\nGOT:  %v", want, tinst.UnwrapCUE())
+	}
+
+	dropped := lac.ByType(LacunaDroppedField)
+	if len(dropped) != 1 {
+		t.Fatalf("expected one DroppedField lacuna, got %v", lac.AsList())
+	}
+	var paths []string
+	for _, f := range dropped[0].SourceFields {
+		paths = append(paths, f.Path)
+	}
+	if got := strings.Join(paths, ","); got != "extra,nested.b,nested.deeper.y" {
+		t.Fatalf("unexpected dropped field paths %s", got)
+	}
+}
+
+func TestTranslateENoVersion(t *testing.T) {
+	lin := transLin(t)
+	ctx := lin.Runtime().Context()
+
+	inst, err := SchemaP(lin, synv(0, 0)).Validate(ctx.CompileString(`{ before: "foo" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = inst.TranslateE(synv(4, 2))
+	if !stderrors.Is(err, terrors.ErrVersionNotExist) {
+		t.Fatalf("expected error wrapping ErrVersionNotExist, got %v", err)
+	}
+	if want := terrors.ErrVersionNotExist.Error() + ": 4.2"; err.Error() != want {
+		t.Fatalf("unexpected error message %q, expected %q", err, want)
+	}
+}
+
+var faillinstr = `
+name: "fail"
+seqs: [
+	{
+		schemas: [
+			{ before: string },
+		]
+	},
+	{
+		schemas: [
+			{ after: "foo" | "bar" },
+		]
+
+		lens: forward: {
+			to:         seqs[1].schemas[0]
+			from:       seqs[0].schemas[0]
+			translated: to & rel
+			rel: after: from.before
+			lacunas: []
+		}
+		lens: reverse: {
+			to:         seqs[0].schemas[0]
+			from:       seqs[1].schemas[0]
+			translated: to & rel
+			rel: before: from.after
+			lacunas: []
+		}
+	},
+]
+`
+
+func TestTranslateELensFailure(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	ctx := rt.Context()
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(ctx.CompileString(faillinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+
+	// The forward lens cannot map values of before other than foo and bar
+	inst, err := SchemaP(lin, synv(0, 0)).Validate(ctx.CompileString(`{ before: "baz" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = inst.TranslateE(synv(1, 0))
+	if !stderrors.Is(err, terrors.ErrTranslationFailed) {
+		t.Fatalf("expected error wrapping ErrTranslationFailed, got %v", err)
+	}
+}
@@ -29,6 +29,62 @@ func FindSeqs(n ast.Node) *ast.ListLit {
 	return ret
 }
 
+// FindSeqsAtPath finds the seqs field of the lineage at the provided CUE path
+// within an ast.Node, typically an *ast.File. Unlike FindSeqs, only the lineage
+// at the path is considered, so other lineages (or seqs fields) elsewhere in
+// the node are ignored.
+//
+// Fields at each path segment may be declared more than once, or be part of a
+// conjunction or embedding (e.g. thema.#Lineage & { ... }); all such
+// declarations are searched. nil is returned if no seqs list is found.
+func FindSeqsAtPath(n ast.Node, p cue.Path) *ast.ListLit {
+	return findSeqsAt(n, p.Selectors())
+}
+
+func findSeqsAt(n ast.Node, sels []cue.Selector) *ast.ListLit {
+	var decls []ast.Decl
+	switch x := n.(type) {
+	case *ast.File:
+		decls = x.Decls
+	case *ast.StructLit:
+		decls = x.Elts
+	case *ast.BinaryExpr:
+		if ret := findSeqsAt(x.X, sels); ret != nil {
+			return ret
+		}
+		return findSeqsAt(x.Y, sels)
+	case *ast.ParenExpr:
+		return findSeqsAt(x.X, sels)
+	default:
+		return nil
+	}
+
+	label := "seqs"
+	if len(sels) > 0 {
+		label = sels[0].String()
+	}
+	for _, d := range decls {
+		switch x := d.(type) {
+		case *ast.Field:
+			if !isFieldWithLabel(x, label) {
+				continue
+			}
+			if len(sels) == 0 {
+				if l, ok := x.Value.(*ast.ListLit); ok {
+					return l
+				}
+			} else if ret := findSeqsAt(x.Value, sels[1:]); ret != nil {
+				return ret
+			}
+		case *ast.EmbedDecl:
+			if ret := findSeqsAt(x.Expr, sels); ret != nil {
+				return ret
+			}
+		}
+	}
+	return nil
+}
+
 // LatestSchemaList finds the ListLit for the latest sequence in what is expected
 // to be a valid lineage ast.Node
 func LatestSchemaList(n ast.Node) (*ast.ListLit, error) {
@@ -0,0 +1,152 @@
+package astutil
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+)
+
+func TestFindSeqsAtPath(t *testing.T) {
+	tt := map[string]struct {
+		src, path string
+		// value of the single element in the seqs list that should be found,
+		// or empty if none should be
+		want string
+	}{
+		"root": {
+			src:  `seqs: ["root"]`,
+			want: `"root"`,
+		},
+		"topLevel": {
+			src: `
+other: seqs: ["other"]
+lin: seqs: ["lin"]
+`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"nested": {
+			src: `
+a: b: seqs: ["wrong"]
+a: b: c: {
+	seqs: ["abc"]
+}
+`,
+			path: "a.b.c",
+			want: `"abc"`,
+		},
+		"ignoresOtherLineages": {
+			src: `
+seqs: ["root"]
+lin: {
+	name: "lin"
+}
+`,
+			path: "lin",
+		},
+		"missingPath": {
+			src:  `lin: seqs: ["lin"]`,
+			path: "nope",
+		},
+		"conjunction": {
+			src: `
+import "github.com/grafana/thema"
+
+lin: thema.#Lineage & {
+	seqs: ["lin"]
+}
+`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"conjunctionRight": {
+			src:  `lin: { name: "lin" } & { other: true } & { seqs: ["lin"] }`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"paren": {
+			src:  `lin: ({ name: "lin" } & ({ seqs: ["lin"] }))`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"embedding": {
+			src: `
+lin: {
+	{ seqs: ["embedded"] }
+	name: "lin"
+}
+`,
+			path: "lin",
+			want: `"embedded"`,
+		},
+		"embeddedConjunction": {
+			src: `
+a: b: {
+	thema.#Lineage & { seqs: ["ab"] }
+}
+`,
+			path: "a.b",
+			want: `"ab"`,
+		},
+		"repeatedField": {
+			src: `
+lin: name: "lin"
+lin: seqs: ["lin"]
+`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"quotedLabel": {
+			src: `
+a: b: "c-d": {
+	seqs: ["quoted"]
+}
+a: b: c: seqs: ["unquoted"]
+`,
+			path: `a.b."c-d"`,
+			want: `"quoted"`,
+		},
+		"quotedIdentifierLabel": {
+			src:  `"lin": "seqs": ["lin"]`,
+			path: "lin",
+			want: `"lin"`,
+		},
+		"nonListSeqs": {
+			src:  `lin: seqs: other.seqs`,
+			path: "lin",
+		},
+	}
+
+	for name, tc := range tt {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			f, err := parser.ParseFile(name+".cue", tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := cue.ParsePath(tc.path)
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			l := FindSeqsAtPath(f, p)
+			if tc.want == "" {
+				if l != nil {
+					t.Fatalf("expected no seqs, got %s", FmtNodeP(l))
+				}
+				return
+			}
+			if l == nil {
+				t.Fatal("expected seqs to be found, got nil")
+			}
+			if len(l.Elts) != 1 {
+				t.Fatalf("expected single-element seqs list, got %s", FmtNodeP(l))
+			}
+			if lit, ok := l.Elts[0].(*ast.BasicLit); !ok || lit.Value != tc.want {
+				t.Fatalf("expected seqs containing %s, got %s", tc.want, FmtNodeP(l))
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package astutil
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+)
+
+// predeclared identifiers in CUE, which must be quoted when used as labels to
+// avoid shadowing them
+var predeclared = map[string]bool{
+	"_": true, "null": true, "true": true, "false": true,
+	"bool": true, "string": true, "bytes": true, "number": true, "int": true, "float": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "int128": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uint128": true,
+	"float32": true, "float64": true, "rune": true,
+	"len": true, "close": true, "and": true, "or": true, "div": true, "mod": true, "quo": true, "rem": true,
+}
+
+// NewLabel returns a label for a regular field with the provided name. The
+// label is an identifier where possible, and a quoted string where the name is
+// not a valid identifier, would shadow a predeclared identifier, or would
+// otherwise declare a hidden field or definition.
+func NewLabel(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") && !predeclared[name] {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
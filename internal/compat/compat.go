@@ -5,6 +5,7 @@ import (
 
 	"cuelang.org/go/cue"
 	"github.com/grafana/thema"
+	"github.com/grafana/thema/compat"
 )
 
 func CheckSeqs(raw cue.Value) error {
@@ -26,13 +27,11 @@ func CheckSeqs(raw cue.Value) error {
 
 			// No predecessor to compare against with the very first schema
 			if !(vminor == 0 && vmaj == 0) {
-				// TODO Marked as buggy until we figure out how to both _not_ require
-				// schema to be closed in the .cue file, _and_ how to detect default changes
+				// TODO Marked as buggy until we figure out how to _not_ require
+				// schema to be closed in the .cue file
 				// if !cfg.skipbuggychecks {
 				// The sequences and schema in the candidate lineage must follow
 				// backwards [in]compatibility rules.
-				// TODO Subsumption may not be what we actually want to check here,
-				// as it does not allow the addition of required fields with defaults
 				bcompat := ThemaCompatible(predecessor, sch)
 				if (vminor == 0 && bcompat == nil) || (vminor != 0 && bcompat != nil) {
 					return &CompatInvariantError{
@@ -54,7 +53,7 @@ func CheckSeqs(raw cue.Value) error {
 }
 
 func ThemaCompatible(p, s cue.Value) error {
-	return s.Subsume(p, cue.Raw(), cue.Schema(), cue.Definitions(true), cue.All(), cue.Final())
+	return compat.ThemaCompatible(p, s)
 }
 
 // Call with no args to get init v, {0, 0}
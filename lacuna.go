@@ -1,9 +1,26 @@
 package thema
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
 // TranslationLacunas defines common patterns for unary and composite lineages
 // in the lacunas their translations emit.
 type TranslationLacunas interface {
+	// AsList returns all lacunas emitted by the translation.
 	AsList() []Lacuna
+
+	// ByType returns the lacunas emitted by the translation that are of the
+	// provided [LacunaType].
+	ByType(LacunaType) []Lacuna
+
+	// HasPlaceholder indicates whether the translation emitted any
+	// [LacunaPlaceholder] lacunas, meaning that the translated instance
+	// contains lens-defined values that the calling program is expected to
+	// replace.
+	HasPlaceholder() bool
 }
 
 type flatLacunas []Lacuna
@@ -12,6 +29,24 @@ func (fl flatLacunas) AsList() []Lacuna {
 	return fl
 }
 
+func (fl flatLacunas) ByType(t LacunaType) []Lacuna {
+	return lacunasByType(fl, t)
+}
+
+func (fl flatLacunas) HasPlaceholder() bool {
+	return len(lacunasByType(fl, LacunaPlaceholder)) > 0
+}
+
+func lacunasByType(all []Lacuna, t LacunaType) []Lacuna {
+	var l []Lacuna
+	for _, lac := range all {
+		if lac.Type == t {
+			l = append(l, lac)
+		}
+	}
+	return l
+}
+
 // A Lacuna represents a semantic gap in a Lens's mapping between schemas.
 //
 // For any given mapping between schema, there may exist some valid values and
@@ -44,9 +79,85 @@ type Lacuna struct {
 
 // LacunaType assigns numeric identifiers to different classes of Lacunas.
 //
-// FIXME this is a terrible way of doing this and needs to change
+// Each LacunaType corresponds to one of the types declared in #LacunaTypes
+// in lacuna.cue, with the same id. See the CUE declarations for the
+// semantics of each type.
 type LacunaType uint16
 
+const (
+	// LacunaPlaceholder indicates that a field in the target instance has
+	// been filled with a placeholder value.
+	LacunaPlaceholder LacunaType = 1
+
+	// LacunaDroppedField indicates that field(s) in the source instance were
+	// dropped in a manner that potentially lost some of their contained
+	// semantics.
+	LacunaDroppedField LacunaType = 2
+
+	// LacunaLossyFieldMapping indicates that no clear mapping existed from the
+	// source field value to the intended semantics of any valid target field
+	// value.
+	LacunaLossyFieldMapping LacunaType = 3
+
+	// LacunaChangedDefault indicates that the source field value was the
+	// schema-specified default, and the default changed in the target field,
+	// and the value in the instance was changed as well.
+	LacunaChangedDefault LacunaType = 4
+)
+
+var lacunaTypeNames = map[LacunaType]string{
+	LacunaPlaceholder:       "Placeholder",
+	LacunaDroppedField:      "DroppedField",
+	LacunaLossyFieldMapping: "LossyFieldMapping",
+	LacunaChangedDefault:    "ChangedDefault",
+}
+
+// String returns the name of the LacunaType, as declared in #LacunaTypes.
+func (t LacunaType) String() string {
+	if name, has := lacunaTypeNames[t]; has {
+		return name
+	}
+	return "LacunaType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// MarshalJSON implements [json.Marshaler], encoding the LacunaType as its
+// name.
+func (t LacunaType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It accepts a LacunaType
+// encoded as its name, as its numeric id, or as the {name, id} struct form of
+// #LacunaType used in CUE.
+func (t *LacunaType) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		for lt, n := range lacunaTypeNames {
+			if n == name {
+				*t = lt
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown lacuna type %q", name)
+	}
+
+	var id uint16
+	if err := json.Unmarshal(b, &id); err == nil {
+		*t = LacunaType(id)
+		return nil
+	}
+
+	var st struct {
+		Name string `json:"name"`
+		ID   uint16 `json:"id"`
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return fmt.Errorf("invalid lacuna type: %w", err)
+	}
+	*t = LacunaType(st.ID)
+	return nil
+}
+
 // FieldRef identifies a path/field and the value in it within a Lacuna.
 type FieldRef struct {
 	Path  string      `json:"path"`
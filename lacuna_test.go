@@ -0,0 +1,151 @@
+package thema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+)
+
+func TestLacunaTypesMatchCUE(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	iter, err := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#LacunaTypes"))).Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	for iter.Next() {
+		count++
+		name := iter.Selector().String()
+		id, err := iter.Value().LookupPath(cue.MakePath(cue.Str("id"))).Int64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := LacunaType(id).String(); got != name {
+			t.Errorf("lacuna type with id %d is named %q in CUE, but %q in Go", id, name, got)
+		}
+	}
+	if count != len(lacunaTypeNames) {
+		t.Errorf("CUE declares %d lacuna types, Go declares %d", count, len(lacunaTypeNames))
+	}
+}
+
+func TestLacunaTypeJSON(t *testing.T) {
+	b, err := json.Marshal(LacunaLossyFieldMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"LossyFieldMapping"` {
+		t.Fatalf("unexpected JSON encoding of lacuna type: %s", b)
+	}
+
+	for _, in := range []string{`"LossyFieldMapping"`, `3`, `{"name": "LossyFieldMapping", "id": 3}`} {
+		var lt LacunaType
+		if err = json.Unmarshal([]byte(in), &lt); err != nil {
+			t.Fatal(err)
+		}
+		if lt != LacunaLossyFieldMapping {
+			t.Errorf("expected %s to decode to LossyFieldMapping, got %s", in, lt)
+		}
+	}
+
+	var lt LacunaType
+	if err = json.Unmarshal([]byte(`"NotALacuna"`), &lt); err == nil {
+		t.Fatal("expected error decoding unknown lacuna type name")
+	}
+}
+
+var placeholderlinstr = `
+name: "placeholder"
+seqs: [
+	{
+		schemas: [
+			{
+				a: string
+			},
+		]
+	},
+	{
+		schemas: [
+			{
+				a: string
+				b: string
+			},
+		]
+
+		lens: forward: {
+			to:         seqs[1].schemas[0]
+			from:       seqs[0].schemas[0]
+			translated: to & rel
+			rel: {
+				a: from.a
+				b: "placeholder"
+			}
+			lacunas: [{
+				targetFields: [{path: "b", value: rel.b}]
+				message: "b is a placeholder"
+				type: {name: "Placeholder", id: 1}
+			}]
+		}
+		lens: reverse: {
+			to:         seqs[0].schemas[0]
+			from:       seqs[1].schemas[0]
+			translated: to & rel
+			rel: {
+				a: from.a
+			}
+			lacunas: [{
+				sourceFields: [{path: "b", value: from.b}]
+				message: "b is dropped"
+				type: {name: "DroppedField", id: 2}
+			}]
+		}
+	},
+]
+`
+
+func TestTranslationLacunasByType(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(placeholderlinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	ctx := rt.Context()
+
+	inst, err := SchemaP(lin, synv(0, 0)).Validate(ctx.CompileString(`{ a: "foo" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lac, err := inst.TranslateE(synv(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lac.HasPlaceholder() {
+		t.Fatal("expected forward translation to report a placeholder")
+	}
+	if pl := lac.ByType(LacunaPlaceholder); len(pl) != 1 || pl[0].Message != "b is a placeholder" {
+		t.Fatalf("unexpected placeholder lacunas: %v", pl)
+	}
+	if dl := lac.ByType(LacunaDroppedField); len(dl) != 0 {
+		t.Fatalf("unexpected dropped field lacunas: %v", dl)
+	}
+
+	inst, err = SchemaP(lin, synv(1, 0)).Validate(ctx.CompileString(`{ a: "foo", b: "bar" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, lac, err = inst.TranslateE(synv(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lac.HasPlaceholder() {
+		t.Fatal("expected reverse translation to report no placeholders")
+	}
+	if dl := lac.ByType(LacunaDroppedField); len(dl) != 1 || dl[0].SourceFields[0].Path != "b" {
+		t.Fatalf("unexpected dropped field lacunas: %v", dl)
+	}
+}
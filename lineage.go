@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"cuelang.org/go/cue"
+	"github.com/grafana/thema/compat"
 	terrors "github.com/grafana/thema/errors"
 )
 
@@ -116,20 +117,13 @@ func BindLineage(raw cue.Value, rt *Runtime, opts ...BindOption) (Lineage, error
 
 			// No predecessor to compare against with the very first schema
 			if !(schv == 0 && seqv == 0) {
-				// TODO Marked as buggy until we figure out how to both _not_ require
-				// schema to be closed in the .cue file, _and_ how to detect default changes
+				// TODO Marked as buggy until we figure out how to _not_ require
+				// schema to be closed in the .cue file
 				if !cfg.skipbuggychecks {
 					// The sequences and schema in the candidate lineage must follow
 					// backwards [in]compatibility rules.
-					// TODO Subsumption may not be what we actually want to check here,
-					// as it does not allow the addition of required fields with defaults
-					bcompat := sch.Subsume(predecessor, cue.Raw(), cue.Schema(), cue.Definitions(true), cue.All(), cue.Final())
-					if (schv == 0 && bcompat == nil) || (schv != 0 && bcompat != nil) {
-						return nil, &compatInvariantError{
-							rawlin:    raw,
-							violation: [2]SyntacticVersion{predsv, v},
-							detail:    bcompat,
-						}
+					if err := checkCompat(raw, predecessor, sch, predsv, v); err != nil {
+						return nil, err
 					}
 				}
 			}
@@ -144,6 +138,22 @@ func BindLineage(raw cue.Value, rt *Runtime, opts ...BindOption) (Lineage, error
 	return lin, nil
 }
 
+// checkCompat checks that the successor schema follows backwards
+// [in]compatibility rules with respect to its predecessor: schemas within
+// a sequence must be backwards compatible, and the first schema in a sequence
+// must be backwards incompatible with the last schema in the prior sequence.
+func checkCompat(rawlin, predecessor, sch cue.Value, predsv, v SyntacticVersion) *compatInvariantError {
+	bcompat := compat.ThemaCompatible(predecessor, sch)
+	if (v[1] == 0 && bcompat == nil) || (v[1] != 0 && bcompat != nil) {
+		return &compatInvariantError{
+			rawlin:    rawlin,
+			violation: [2]SyntacticVersion{predsv, v},
+			detail:    bcompat,
+		}
+	}
+	return nil
+}
+
 func sanitizeLabelString(s string) string {
 	return strings.Map(func(r rune) rune {
 		switch {
@@ -174,6 +184,10 @@ func isValidLineage(lin Lineage) {
 		if !tlin.validated {
 			panic("lineage not validated")
 		}
+	case *CompositeLineage:
+		for _, mlin := range tlin.members {
+			isValidLineage(mlin)
+		}
 	default:
 		panic("unreachable")
 	}
@@ -183,6 +197,20 @@ func getLinLib(lin Lineage) *Runtime {
 	switch tlin := lin.(type) {
 	case *UnaryLineage:
 		return tlin.rt
+	case *CompositeLineage:
+		return tlin.rt
+	default:
+		panic("unreachable")
+	}
+}
+
+// allVersions returns the sorted list of all schema versions in the lineage.
+func allVersions(lin Lineage) []SyntacticVersion {
+	switch tlin := lin.(type) {
+	case *UnaryLineage:
+		return tlin.allv
+	case *CompositeLineage:
+		return tlin.allv
 	default:
 		panic("unreachable")
 	}
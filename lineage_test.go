@@ -0,0 +1,52 @@
+package thema
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema/compat"
+)
+
+var defchangelinstr = `
+name: "defchange"
+seqs: [
+	{
+		schemas: [
+			{
+				aunion: *"foo" | "bar" | "baz"
+			},
+			{
+				aunion: "foo" | *"bar" | "baz"
+			},
+		]
+	},
+]
+`
+
+func TestBindLineageDefaultChange(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(defchangelinstr))
+
+	_, err := BindLineage(val, rt)
+	if err == nil {
+		t.Fatal("expected BindLineage to reject default change within a sequence")
+	}
+
+	var dcerr *compat.DefaultChangeError
+	if !stderrors.As(err, &dcerr) {
+		t.Fatalf("expected error to contain a DefaultChangeError, got %s", err)
+	}
+	for _, s := range []string{"aunion", `"foo"`, `"bar"`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("expected error message to contain %s, got %s", s, err)
+		}
+	}
+
+	vs := CheckLineage(val, rt)
+	if len(vs) != 1 || strings.Join(vs[0].Path, ".") != "aunion" {
+		t.Fatalf("expected a single violation for aunion, got %v", vs)
+	}
+}
@@ -0,0 +1,153 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
+	"github.com/grafana/thema"
+)
+
+// Catalog loads every lineage found in the CUE module contained in modFS, and
+// returns a [thema.Catalog] indexing all of them.
+//
+// Each directory in the module (other than cue.mod and hidden directories)
+// containing .cue files is loaded with [InstancesWithThema], once for each CUE
+// package declared by its files. Within each loaded package, lineages are
+// found by searching through regular (non-definition, non-hidden) fields for
+// struct values having both a name and a seqs field. All such values must be
+// valid lineages, and are bound with the provided BindOptions.
+//
+// The same constraints on modFS apply as for InstancesWithThema. An
+// [ErrFSNotACueModule] is returned if modFS has no cue.mod/module.cue file.
+func Catalog(modFS fs.FS, rt *thema.Runtime, opts ...thema.BindOption) (*thema.Catalog, error) {
+	if _, err := fs.Stat(modFS, path.Join("cue.mod", "module.cue")); err != nil {
+		return nil, &ErrFSNotACueModule{fserr: err}
+	}
+
+	pkgdirs, err := findPackages(modFS)
+	if err != nil {
+		return nil, err
+	}
+
+	var lins []thema.Lineage
+	for _, pd := range pkgdirs {
+		binst, err := InstancesWithThema(modFS, pd.dir, Package(pd.pkg))
+		if err != nil {
+			return nil, fmt.Errorf("%s (package %s): %w", pd.dir, pd.pkg, err)
+		}
+
+		v := rt.Context().BuildInstance(binst)
+		if v.Err() != nil {
+			return nil, fmt.Errorf("%s (package %s): %w", pd.dir, pd.pkg, v.Err())
+		}
+
+		found, err := findLineages(v, rt, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s (package %s): %w", pd.dir, pd.pkg, err)
+		}
+		lins = append(lins, found...)
+	}
+
+	return thema.NewCatalog(lins...)
+}
+
+type pkgdir struct {
+	dir, pkg string
+}
+
+// findPackages walks modFS, returning each directory and CUE package pair
+// declared by the .cue files within it. All returned errors identify the path
+// of the file at which they occurred; walk and open errors are *fs.PathErrors,
+// and read and parse errors are prefixed with the path.
+func findPackages(modFS fs.FS) ([]pkgdir, error) {
+	seen := make(map[pkgdir]bool)
+	var pkgdirs []pkgdir
+	err := fs.WalkDir(modFS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p != "." && (p == "cue.mod" || strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(p) != ".cue" {
+			return nil
+		}
+
+		f, err := modFS.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+
+		pf, err := parser.ParseFile(p, b, parser.PackageClauseOnly)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if pf.PackageName() == "" {
+			return nil
+		}
+
+		pd := pkgdir{dir: path.Dir(p), pkg: pf.PackageName()}
+		if !seen[pd] {
+			seen[pd] = true
+			pkgdirs = append(pkgdirs, pd)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pkgdirs, func(i, j int) bool {
+		if pkgdirs[i].dir != pkgdirs[j].dir {
+			return pkgdirs[i].dir < pkgdirs[j].dir
+		}
+		return pkgdirs[i].pkg < pkgdirs[j].pkg
+	})
+	return pkgdirs, nil
+}
+
+// findLineages recursively searches the regular fields of v for lineages,
+// binding each one found.
+func findLineages(v cue.Value, rt *thema.Runtime, opts []thema.BindOption) ([]thema.Lineage, error) {
+	if v.IncompleteKind() != cue.StructKind {
+		return nil, nil
+	}
+
+	if v.LookupPath(cue.MakePath(cue.Str("name"))).Exists() && v.LookupPath(cue.MakePath(cue.Str("seqs"))).Exists() {
+		lin, err := thema.BindLineage(v, rt, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return []thema.Lineage{lin}, nil
+	}
+
+	var lins []thema.Lineage
+	iter, err := v.Fields()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		found, err := findLineages(iter.Value(), rt, opts)
+		if err != nil {
+			return nil, err
+		}
+		lins = append(lins, found...)
+	}
+	return lins, nil
+}
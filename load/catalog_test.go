@@ -0,0 +1,136 @@
+package load
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
+)
+
+func TestCatalog(t *testing.T) {
+	tfs, err := fs.Sub(testdataFS, "testdata/testmod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := thema.NewRuntime(cuecontext.New())
+	cat, err := Catalog(tfs, rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := cat.Names(); !reflect.DeepEqual(names, []string{"Dock", "Ship"}) {
+		t.Fatalf("unexpected lineages in catalog: %v", names)
+	}
+
+	sch, err := cat.Schema("Dock@v0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sch.Lineage().Name() != "Dock" || sch.Version() != thema.SV(0, 1) {
+		t.Fatalf("unexpected schema %s@%s", sch.Lineage().Name(), sch.Version())
+	}
+	if _, err = cat.Schema("Dock@2.0"); !errors.Is(err, terrors.ErrVersionNotExist) {
+		t.Fatalf("expected ErrVersionNotExist, got %v", err)
+	}
+	if _, err = cat.Schema("Barge@0.0"); !errors.Is(err, terrors.ErrLineageNotExist) {
+		t.Fatalf("expected ErrLineageNotExist, got %v", err)
+	}
+
+	ctx := rt.Context()
+	lin, err := cat.Detect(ctx.CompileString(`{ kind: "dock", berths: 4 }`), "kind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lin.Name() != "Dock" {
+		t.Fatalf("expected payload to be detected as Dock, got %s", lin.Name())
+	}
+	if inst := lin.ValidateAny(ctx.CompileString(`{ kind: "Dock", berths: 4 }`)); inst == nil {
+		t.Fatal("expected payload to validate against detected lineage")
+	}
+
+	if _, err = cat.Detect(ctx.CompileString(`{ kind: "Barge" }`), "kind"); !errors.Is(err, terrors.ErrLineageNotExist) {
+		t.Fatalf("expected ErrLineageNotExist, got %v", err)
+	}
+	if _, err = cat.Detect(ctx.CompileString(`{ berths: 4 }`), "kind"); !errors.Is(err, terrors.ErrValueNotExist) {
+		t.Fatalf("expected ErrValueNotExist, got %v", err)
+	}
+}
+
+// readErrFS is an fs.FS in which reads of the named file fail.
+type readErrFS struct {
+	fs.FS
+	name string
+}
+
+func (rfs readErrFS) Open(name string) (fs.File, error) {
+	f, err := rfs.FS.Open(name)
+	if err != nil || name != rfs.name {
+		return f, err
+	}
+	return readErrFile{f}, nil
+}
+
+type readErrFile struct {
+	fs.File
+}
+
+func (readErrFile) Read([]byte) (int, error) {
+	return 0, errors.New("read failure")
+}
+
+func TestCatalogErrors(t *testing.T) {
+	mod := func(files map[string]string) fstest.MapFS {
+		mfs := fstest.MapFS{
+			"cue.mod/module.cue": &fstest.MapFile{Data: []byte(`module: "example.com/testmod"`)},
+		}
+		for name, content := range files {
+			mfs[name] = &fstest.MapFile{Data: []byte(content)}
+		}
+		return mfs
+	}
+
+	tt := map[string]struct {
+		fs fs.FS
+		// whether an ErrFSNotACueModule is expected
+		notmod bool
+		// expected prefix of the error message
+		prefix string
+	}{
+		"noModule": {
+			fs:     fstest.MapFS{"ship.cue": &fstest.MapFile{Data: []byte("package testmod\n")}},
+			notmod: true,
+		},
+		"parseError": {
+			fs:     mod(map[string]string{"kinds/dock.cue": "package 1\n"}),
+			prefix: "kinds/dock.cue: ",
+		},
+		"readError": {
+			fs:     readErrFS{FS: mod(map[string]string{"kinds/dock.cue": "package kinds\n"}), name: "kinds/dock.cue"},
+			prefix: "kinds/dock.cue: read failure",
+		},
+	}
+
+	rt := thema.NewRuntime(cuecontext.New())
+	for name, tc := range tt {
+		_, err := Catalog(tc.fs, rt)
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+			continue
+		}
+
+		var nmerr *ErrFSNotACueModule
+		if errors.As(err, &nmerr) != tc.notmod {
+			t.Errorf("%s: expected ErrFSNotACueModule to be %t, got %v", name, tc.notmod, err)
+		}
+		if !strings.HasPrefix(err.Error(), tc.prefix) {
+			t.Errorf("%s: expected error prefixed with %q, got %q", name, tc.prefix, err)
+		}
+	}
+}
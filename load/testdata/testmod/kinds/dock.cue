@@ -0,0 +1,21 @@
+package kinds
+
+import "github.com/grafana/thema"
+
+dock: lineage: thema.#Lineage
+dock: lineage: name: "Dock"
+dock: lineage: seqs: [
+    {
+        schemas: [
+            { // 0.0
+                kind: "Dock"
+                berths: int
+            },
+            { // 0.1
+                kind: "Dock"
+                berths: int
+                covered?: bool
+            },
+        ]
+    },
+]
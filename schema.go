@@ -50,7 +50,7 @@ func (sch *UnarySchema) Validate(data cue.Value) (*Instance, error) {
 
 	x := sch.defraw.Unify(data)
 	if err := x.Validate(cue.Final(), cue.All()); err != nil {
-		return nil, mungeValidateErr(err, sch)
+		return nil, mungeValidateErr(err, sch, data)
 	}
 
 	return &Instance{
@@ -189,14 +189,17 @@ func BindType[T Assignee](sch Schema, t T) (TypedSchema[T], error) {
 }
 
 func schemaIs(s1, s2 Schema) bool {
-	// TODO will need something smarter here if/when we have more types representing schema
-	vs1, is1 := s1.(*UnarySchema)
-	vs2, is2 := s2.(*UnarySchema)
-	if !is1 || !is2 {
+	switch vs1 := s1.(type) {
+	case *UnarySchema:
+		vs2, is := s2.(*UnarySchema)
+		return is && vs1 == vs2
+	case *CompositeSchema:
+		vs2, is := s2.(*CompositeSchema)
+		return is && vs1 == vs2
+	default:
 		panic(fmt.Sprintf("TODO implement schema comparison handler for types %T and %T", s1, s2))
 		return false
 	}
-	return vs1 == vs2
 }
 
 type unaryTypedSchema[T Assignee] struct {
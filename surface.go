@@ -70,12 +70,8 @@ func SchemaP(lin Lineage, v SyntacticVersion) Schema {
 func LatestVersion(lin Lineage) SyntacticVersion {
 	isValidLineage(lin)
 
-	switch tlin := lin.(type) {
-	case *UnaryLineage:
-		return tlin.allv[len(tlin.allv)-1]
-	default:
-		panic("unreachable")
-	}
+	allv := allVersions(lin)
+	return allv[len(allv)-1]
 }
 
 // LatestVersionInSequence returns the version number of the newest (largest) schema
@@ -85,19 +81,15 @@ func LatestVersion(lin Lineage) SyntacticVersion {
 func LatestVersionInSequence(lin Lineage, seqv uint) (SyntacticVersion, error) {
 	isValidLineage(lin)
 
-	switch tlin := lin.(type) {
-	case *UnaryLineage:
-		latest := tlin.allv[len(tlin.allv)-1]
-		switch {
-		case latest[0] < seqv:
-			return synv(), fmt.Errorf("lineage does not contain a sequence with number %v", seqv)
-		case latest[0] == seqv:
-			return latest, nil
-		default:
-			return tlin.allv[searchSynv(tlin.allv, SyntacticVersion{seqv + 1, 0})], nil
-		}
+	allv := allVersions(lin)
+	latest := allv[len(allv)-1]
+	switch {
+	case latest[0] < seqv:
+		return synv(), fmt.Errorf("lineage does not contain a sequence with number %v", seqv)
+	case latest[0] == seqv:
+		return latest, nil
 	default:
-		panic("unreachable")
+		return allv[searchSynv(allv, SyntacticVersion{seqv + 1, 0})], nil
 	}
 }
 
@@ -286,7 +278,7 @@ func SV(seqv, schv uint) SyntacticVersion {
 // Less reports whether the receiver [SyntacticVersion] is less than the
 // provided one, consistent with the expectations of the stdlib sort package.
 func (sv SyntacticVersion) Less(osv SyntacticVersion) bool {
-	return sv[0] < osv[0] || sv[1] < osv[1]
+	return sv[0] < osv[0] || (sv[0] == osv[0] && sv[1] < osv[1])
 }
 
 func (sv SyntacticVersion) String() string {
@@ -7,12 +7,16 @@ import "list"
 // list of schemas, starting at the version the instance is valid against, and
 // continuing until the target schema version is reached.
 //
+// Translation may proceed in either direction. When the target version is
+// older than the instance's version, the list of schemas is walked in reverse:
+// within a sequence, the instance is narrowed to the older schema, and across
+// sequences, the reverse lens is applied.
+//
 // The out values are the instance in final translated form, the schema versions
 // at which the translation started and ended, and any lacunas emitted during
 // translation.
 //
 // TODO functionize
-// TODO backwards-translation is not yet supported
 #Translate: {
     linst: #LinkedInstance
     to: #SyntacticVersion
@@ -46,7 +50,14 @@ import "list"
             let lasti = accum[i]
             v: vsch.v
 
-            if vsch.v[0] == lasti.v[0] {
+            if vsch.v[0] == lasti.v[0] && vsch.v[1] < lasti.v[1] {
+                // Same sequence, backwards. Narrow the instance to the older
+                // schema by dropping any fields it does not allow.
+                let _narrow = (_#narrow & { inst: lasti.inst, sch: inlin.seqs[vsch.v[0]].schemas[vsch.v[1]] })
+                inst: _narrow.out & inlin.seqs[vsch.v[0]].schemas[vsch.v[1]]
+                lacunas: _narrow.lacunas
+            }
+            if vsch.v[0] == lasti.v[0] && vsch.v[1] > lasti.v[1] {
                 // Same sequence. Translation is through the implicit lens;
                 // simple unification.
 
@@ -67,6 +78,13 @@ import "list"
                 inst: _lens.translated
                 lacunas: _lens.lacunas
             }
+            if vsch.v[0] < lasti.v[0] {
+                // Crossing sequences backwards. Translate via the explicit
+                // reverse lens, which lives on the sequence being left.
+                let _lens = { from: lasti.inst } & inlin.seqs[lasti.v[0]].lens.reverse
+                inst: _lens.translated
+                lacunas: _lens.lacunas
+            }
         }]
 
         out: {
@@ -90,10 +108,87 @@ import "list"
             list.Slice((_all & { lin: inlin }).out, lo+1, hi+1)
         }
         if cmp == 1 {
-            // FIXME For now, we don't support backwards translation. This must change.
-            _|_
+            // Walk backwards, from the immediate predecessor of VF down to VT
+            let lo = (_flatidx & { lin: inlin, v: VT }).out
+            let hi = (_flatidx & { lin: inlin, v: VF }).out
+            let fwd = list.Slice((_all & { lin: inlin }).out, lo, hi)
+            [for i, _ in fwd { fwd[len(fwd)-1-i] }]
         }
     }
 
     out: (_transl & { schemarange: schrange }).out
 }
+
+// Helper that narrows a concrete struct instance to the fields allowed by an
+// older schema in the same sequence, emitting a DroppedField lacuna if any
+// fields had to be removed. Fields whose value and schema are both structs are
+// narrowed recursively.
+_#narrow: {
+    inst: {...}
+    sch: _
+
+    let I = inst
+    let SCH = sch
+    let _n = (_narrowLevels["0"] & { inst: I, sch: SCH })
+
+    out: _n.out
+    lacunas: [
+        if len(_n.dropped) > 0 {
+            #Lacuna & {
+                sourceFields: _n.dropped
+                message: "fields not allowed by the older schema were dropped"
+                type: #LacunaTypes.DroppedField
+            }
+        },
+    ]
+}
+
+// Maximum depth of nested structs narrowed by _#narrow. Structs nested more
+// deeply are kept or dropped as a whole.
+_narrowDepth: 16
+
+// CUE does not allow a definition to refer to itself, so _#narrow's recursion
+// is unrolled into _narrowDepth levels, each of which refers to the next.
+_narrowLevels: {
+    for i in list.Range(0, _narrowDepth, 1) {
+        "\(i)": (_#narrowLevel & { next: _narrowLevels["\(i+1)"] }).fn
+    }
+    "\(_narrowDepth)": null
+}
+
+_#narrowLevel: {
+    next: _
+    fn: {
+        inst: {...}
+        sch: _
+        // Path of inst within the instance being narrowed, as a prefix for
+        // the paths of dropped fields
+        prefix: *"" | string
+
+        let P = prefix
+        let SCH = sch
+
+        // Narrowing of each field whose value and schema are both structs
+        _nested: {
+            for k, v in inst if (close(sch) & { (k): _ }) != _|_ && (v & {...}) != _|_ && ((close(sch) & { (k): _ })[k] & {...}) != _|_ {
+                (k): next & { inst: v, sch: (close(SCH) & { (k): _ })[k], prefix: "\(P)\(k)." }
+            }
+        }
+
+        dropped: list.Concat([
+            [for k, v in inst if _nested[k] == _|_ && (close(sch) & { (k): v }) == _|_ { path: "\(prefix)\(k)", value: v }],
+            for k, n in _nested { n.dropped },
+        ])
+
+        out: {
+            for k, v in inst {
+                if _nested[k] != _|_ {
+                    (k): _nested[k].out
+                }
+                if _nested[k] == _|_ && (close(sch) & { (k): v }) != _|_ {
+                    (k): v
+                }
+            }
+        }
+    }
+}
@@ -2,7 +2,9 @@ package thema
 
 import (
 	"bytes"
+	stderrors "errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"cuelang.org/go/cue"
@@ -11,36 +13,82 @@ import (
 	terrors "github.com/grafana/thema/errors"
 )
 
-type onesidederr struct {
-	schpos, datapos []token.Pos
-	code            terrors.ValidationCode
-	coords          coords
-	val             string
+// ValidationError describes a single way in which data failed validation
+// against a Thema schema.
+//
+// Errors returned from [Schema.Validate] contain one or more ValidationErrors.
+// Use [IterValidationErrors] to iterate over them.
+//
+// All ValidationErrors wrap the general [terrors.ErrNotAnInstance] sentinel
+// error, as well as the more specific sentinel error corresponding to their
+// Code, e.g. [terrors.ErrInvalidMissingField] for [terrors.MissingField].
+type ValidationError struct {
+	// Schema is the schema against which validation failed.
+	Schema Schema
+
+	// Path is the path to the field that failed validation, relative to the
+	// root of the schema.
+	Path []string
+
+	// SchemaPos are the source positions in the schema relevant to the
+	// failure.
+	SchemaPos []token.Pos
+
+	// DataPos are the source positions in the data relevant to the failure.
+	DataPos []token.Pos
+
+	// Expected is the schema's constraint on the field. Empty for
+	// [terrors.ExcessField] failures.
+	Expected string
+
+	// Actual is the value of the field in the data. Empty for
+	// [terrors.MissingField] failures.
+	Actual string
+
+	// Code is the class of validation failure.
+	Code terrors.ValidationCode
+}
+
+func (e *ValidationError) coords() coords {
+	return coords{
+		sch:       e.Schema,
+		fieldpath: e.Path,
+	}
 }
 
-func (e *onesidederr) Error() string {
+func (e *ValidationError) Error() string {
 	var buf bytes.Buffer
 
-	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:", e.coords)
-	switch e.code {
+	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:", e.coords())
+	switch e.Code {
 	case terrors.MissingField:
-		fmt.Fprintf(&buf, "\n\tschema specifies that field exists with type %v", e.val)
-		for _, pos := range e.schpos {
+		fmt.Fprintf(&buf, "\n\tschema specifies that field exists with type %v", e.Expected)
+		for _, pos := range e.SchemaPos {
 			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
 		}
 
 		fmt.Fprintf(&buf, "\n\tbut field was absent from data")
-		for _, pos := range e.datapos {
+		for _, pos := range e.DataPos {
 			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
 		}
 	case terrors.ExcessField:
 		fmt.Fprintf(&buf, "\n\tschema is closed and does not specify field")
-		for _, pos := range e.schpos {
+		for _, pos := range e.SchemaPos {
+			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
+		}
+
+		fmt.Fprintf(&buf, "\n\tbut field exists in data with value %v", e.Actual)
+		for _, pos := range e.DataPos {
+			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
+		}
+	default:
+		fmt.Fprintf(&buf, "\n\tschema expected `%s`", e.Expected)
+		for _, pos := range e.SchemaPos {
 			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
 		}
 
-		fmt.Fprintf(&buf, "\n\tbut field exists in data with value %v", e.val)
-		for _, pos := range e.datapos {
+		fmt.Fprintf(&buf, "\n\tbut data contained `%s`", e.Actual)
+		for _, pos := range e.DataPos {
 			fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
 		}
 	}
@@ -48,34 +96,25 @@ func (e *onesidederr) Error() string {
 	return buf.String()
 }
 
-func (e *onesidederr) Unwrap() error {
+// Unwrap implements standard Go error unwrapping, relied on by errors.Is.
+func (e *ValidationError) Unwrap() error {
 	return terrors.ErrNotAnInstance
 }
 
-type twosidederr struct {
-	schpos, datapos []token.Pos
-	code            terrors.ValidationCode
-	coords          coords
-	sv, dv          string
-}
-
-func (e *twosidederr) Error() string {
-	var buf bytes.Buffer
-
-	fmt.Fprintf(&buf, "%s: validation failed, data is not an instance:\n\tschema expected `%s`", e.coords, e.sv)
-	for _, pos := range e.schpos {
-		fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
-	}
-
-	fmt.Fprintf(&buf, "\n\tbut data contained `%s`", e.dv)
-	for _, pos := range e.datapos {
-		fmt.Fprintf(&buf, "\n\t\t%s", pos.String())
+// Is reports whether the target is the sentinel error corresponding to the
+// ValidationError's Code. It is relied on by errors.Is.
+func (e *ValidationError) Is(target error) bool {
+	switch e.Code {
+	case terrors.KindConflict:
+		return target == terrors.ErrInvalidKindConflict
+	case terrors.OutOfBounds:
+		return target == terrors.ErrInvalidOutOfBounds
+	case terrors.MissingField:
+		return target == terrors.ErrInvalidMissingField
+	case terrors.ExcessField:
+		return target == terrors.ErrInvalidExcessField
 	}
-	return buf.String()
-}
-
-func (e *twosidederr) Unwrap() error {
-	return terrors.ErrNotAnInstance
+	return false
 }
 
 // TODO differentiate this once we have generic composition to support trimming out irrelevant disj branches
@@ -91,6 +130,47 @@ func (e *emptydisjunction) Unwrap() error {
 
 type validationFailure []error
 
+// ValidationErrorIterator iterates over the individual failures contained in
+// an error returned from [Schema.Validate].
+type ValidationErrorIterator struct {
+	errs []error
+	cur  int
+}
+
+// IterValidationErrors returns an iterator over all of the individual
+// validation failures contained in the provided error, which is expected to
+// have been returned from [Schema.Validate].
+//
+// If the error contains no validation failures, the returned iterator is
+// empty.
+func IterValidationErrors(err error) *ValidationErrorIterator {
+	var vf validationFailure
+	if !stderrors.As(err, &vf) {
+		return &ValidationErrorIterator{cur: -1}
+	}
+	return &ValidationErrorIterator{errs: vf, cur: -1}
+}
+
+// Next advances the iterator to the next failure, returning false if there
+// are no more failures.
+func (it *ValidationErrorIterator) Next() bool {
+	it.cur++
+	return it.cur < len(it.errs)
+}
+
+// Err returns the current failure.
+func (it *ValidationErrorIterator) Err() error {
+	return it.errs[it.cur]
+}
+
+// ValidationError returns the current failure as a [ValidationError]. It
+// returns nil if Thema was unable to produce a structured error for the
+// failure, in which case only Err is available.
+func (it *ValidationErrorIterator) ValidationError() *ValidationError {
+	ve, _ := it.errs[it.cur].(*ValidationError)
+	return ve
+}
+
 func (vf validationFailure) Unwrap() error {
 	return terrors.ErrNotAnInstance
 }
@@ -105,7 +185,7 @@ func (vf validationFailure) Error() string {
 	return buf.String()
 }
 
-func mungeValidateErr(err error, sch Schema) error {
+func mungeValidateErr(err error, sch Schema, data cue.Value) error {
 	_, is := err.(errors.Error)
 	if !is {
 		return err
@@ -114,10 +194,7 @@ func mungeValidateErr(err error, sch Schema) error {
 	var errs validationFailure
 	for _, ee := range errors.Errors(err) {
 		schpos, datapos := splitTokens(ee.InputPositions())
-		x := coords{
-			sch:       sch,
-			fieldpath: trimThemaPath(ee.Path()),
-		}
+		path := trimThemaPath(ee.Path())
 
 		msg, vals := ee.Msg()
 		switch len(vals) {
@@ -126,23 +203,47 @@ func mungeValidateErr(err error, sch Schema) error {
 			if !ok {
 				break
 			}
-			err := &onesidederr{
-				schpos:  schpos,
-				datapos: datapos,
-				coords:  x,
-				val:     val,
+			err := &ValidationError{
+				Schema:    sch,
+				Path:      path,
+				SchemaPos: schpos,
+				DataPos:   datapos,
 			}
 
 			if strings.Contains(msg, "incomplete") {
-				err.code = terrors.MissingField
+				err.Code = terrors.MissingField
+				err.Expected = val
 			} else if strings.Contains(msg, "not allowed") {
-				err.code = terrors.ExcessField
+				// CUE reports the path of the struct containing the excess
+				// field, and the field name as the value
+				err.Code = terrors.ExcessField
+				err.Path = append(path, val)
+				if dv := data.LookupPath(dataPath(data, err.Path)); dv.Exists() {
+					err.Actual = fmt.Sprint(dv)
+				}
 			} else {
 				break
 			}
 
 			errs = append(errs, err)
 			continue
+		case 2:
+			dataval, dvok := vals[0].(string)
+			schval, svok := vals[1].(string)
+			if !dvok || !svok || !strings.Contains(msg, "out of bound") {
+				break
+			}
+
+			errs = append(errs, &ValidationError{
+				Schema:    sch,
+				Path:      path,
+				SchemaPos: schpos,
+				DataPos:   datapos,
+				Expected:  schval,
+				Actual:    dataval,
+				Code:      terrors.OutOfBounds,
+			})
+			continue
 		case 4:
 			schval, svok := vals[0].(string)
 			dataval, dvok := vals[1].(string)
@@ -152,17 +253,18 @@ func mungeValidateErr(err error, sch Schema) error {
 				break
 			}
 
-			err := &twosidederr{
-				schpos:  schpos,
-				datapos: datapos,
-				coords:  x,
-				sv:      schval,
-				dv:      dataval,
+			err := &ValidationError{
+				Schema:    sch,
+				Path:      path,
+				SchemaPos: schpos,
+				DataPos:   datapos,
+				Expected:  schval,
+				Actual:    dataval,
 			}
 			if datakind.IsAnyOf(schkind) {
-				err.code = terrors.OutOfBounds
+				err.Code = terrors.OutOfBounds
 			} else {
-				err.code = terrors.KindConflict
+				err.Code = terrors.KindConflict
 			}
 
 			errs = append(errs, err)
@@ -194,6 +296,27 @@ func splitTokens(poslist []token.Pos) (schpos, datapos []token.Pos) {
 	return poslist[:split], poslist[split:]
 }
 
+// dataPath converts a path, as reported by CUE errors, to a cue.Path within
+// data. Elements are treated as list indices where data contains a list, and
+// quoted labels are unquoted.
+func dataPath(data cue.Value, parts []string) cue.Path {
+	sels := make([]cue.Selector, 0, len(parts))
+	for _, part := range parts {
+		if uq, err := strconv.Unquote(part); err == nil {
+			part = uq
+		}
+		sel := cue.Str(part)
+		if data.IncompleteKind() == cue.ListKind {
+			if i, err := strconv.Atoi(part); err == nil {
+				sel = cue.Index(i)
+			}
+		}
+		sels = append(sels, sel)
+		data = data.LookupPath(cue.MakePath(sel))
+	}
+	return cue.MakePath(sels...)
+}
+
 func trimThemaPath(parts []string) []string {
 	for i, s := range parts {
 		if s == "seqs" {
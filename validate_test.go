@@ -1 +1,176 @@
 package thema
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	terrors "github.com/grafana/thema/errors"
+)
+
+var validatelinstr = `
+name: "validate"
+seqs: [
+	{
+		schemas: [
+			{
+				str: string
+				num: int & <10
+				nested: {
+					b: bool
+				}
+				list?: [...{ x: int }]
+				"dash-key"?: {
+					y: int
+				}
+			},
+		]
+	},
+]
+`
+
+func TestValidationErrors(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(validatelinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	sch := SchemaP(lin, synv(0, 0))
+
+	table := map[string]struct {
+		data     string
+		path     string
+		code     terrors.ValidationCode
+		sentinel error
+		actual   string
+	}{
+		"kind conflict": {
+			data:     `{ str: 42, num: 1, nested: { b: true } }`,
+			path:     "str",
+			code:     terrors.KindConflict,
+			sentinel: terrors.ErrInvalidKindConflict,
+		},
+		"out of bounds": {
+			data:     `{ str: "foo", num: 42, nested: { b: true } }`,
+			path:     "num",
+			code:     terrors.OutOfBounds,
+			sentinel: terrors.ErrInvalidOutOfBounds,
+		},
+		"excess field": {
+			data:     `{ str: "foo", num: 1, nested: { b: true, c: "bar" } }`,
+			path:     "nested.c",
+			code:     terrors.ExcessField,
+			sentinel: terrors.ErrInvalidExcessField,
+			actual:   `"bar"`,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := sch.Validate(rt.Context().CompileString(tt.data, cue.Filename("data.cue")))
+			if err == nil {
+				t.Fatal("expected validation to fail")
+			}
+			if !stderrors.Is(err, terrors.ErrNotAnInstance) {
+				t.Fatalf("expected error to wrap ErrNotAnInstance, got %s", err)
+			}
+
+			var ve *ValidationError
+			var n int
+			iter := IterValidationErrors(err)
+			for iter.Next() {
+				n++
+				ve = iter.ValidationError()
+				if ve == nil {
+					t.Fatalf("expected a structured validation error, got %s", iter.Err())
+				}
+			}
+			if n != 1 {
+				t.Fatalf("expected exactly one validation failure, got %d:\n%s", n, err)
+			}
+
+			if ve.Code != tt.code {
+				t.Errorf("expected code %d, got %d", tt.code, ve.Code)
+			}
+			if got := ve.coords().String(); got != "<validate@v0.0>."+tt.path {
+				t.Errorf("unexpected coords %s", got)
+			}
+			if len(ve.SchemaPos) == 0 || len(ve.DataPos) == 0 {
+				t.Errorf("expected schema and data positions, got %v and %v", ve.SchemaPos, ve.DataPos)
+			}
+			if tt.code == terrors.ExcessField && ve.Actual != tt.actual {
+				t.Errorf("expected actual value of excess field, got %s", ve.Actual)
+			}
+			if !stderrors.Is(ve, tt.sentinel) {
+				t.Errorf("expected error to match sentinel %q", tt.sentinel)
+			}
+			if stderrors.Is(ve, terrors.ErrInvalidMissingField) {
+				t.Errorf("error should not match unrelated sentinel")
+			}
+		})
+	}
+}
+
+func TestValidationErrorExcessActual(t *testing.T) {
+	rt := NewRuntime(cuecontext.New())
+	val := rt.UnwrapCUE().LookupPath(cue.MakePath(cue.Def("#Lineage"))).Unify(rt.Context().CompileString(validatelinstr))
+	lin, err := BindLineage(val, rt)
+	if err != nil {
+		t.Fatal(errors.Details(err, nil))
+	}
+	sch := SchemaP(lin, synv(0, 0))
+
+	table := map[string]struct {
+		data   string
+		path   []string
+		actual string
+	}{
+		"list element": {
+			data:   `{ str: "foo", num: 1, nested: { b: true }, list: [{ x: 1 }, { x: 2, c: 3 }] }`,
+			path:   []string{"list", "1", "c"},
+			actual: "3",
+		},
+		"quoted label": {
+			data:   `{ str: "foo", num: 1, nested: { b: true }, "dash-key": { y: 1, c: "baz" } }`,
+			path:   []string{`"dash-key"`, "c"},
+			actual: `"baz"`,
+		},
+	}
+
+	for name, tt := range table {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := sch.Validate(rt.Context().CompileString(tt.data, cue.Filename("data.cue")))
+			if err == nil {
+				t.Fatal("expected validation to fail")
+			}
+
+			iter := IterValidationErrors(err)
+			if !iter.Next() {
+				t.Fatal("expected a validation failure")
+			}
+			ve := iter.ValidationError()
+			if ve == nil || ve.Code != terrors.ExcessField {
+				t.Fatalf("expected a structured excess field error, got %s", iter.Err())
+			}
+			if strings.Join(ve.Path, ".") != strings.Join(tt.path, ".") {
+				t.Errorf("expected path %v, got %v", tt.path, ve.Path)
+			}
+			if ve.Actual != tt.actual {
+				t.Errorf("expected actual value %s, got %s", tt.actual, ve.Actual)
+			}
+		})
+	}
+}
+
+func TestIterValidationErrorsEmpty(t *testing.T) {
+	iter := IterValidationErrors(stderrors.New("not a validation error"))
+	if iter.Next() {
+		t.Fatal("expected empty iterator for non-validation error")
+	}
+}
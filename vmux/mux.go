@@ -22,12 +22,16 @@ type UntypedMux func(b []byte) (*thema.Instance, thema.TranslationLacunas, error
 //
 //   - Decode the input []byte using the provided [Decoder], then
 //   - Pass the result to [thema.Schema.Validate], then
-//   - Call [thema.Instance.Translate] on the result, to the version of the provided [thema.Schema], then
+//   - Call [thema.Instance.TranslateE] on the result, to the version of the provided [thema.Schema], then
 //   - Return the resulting [thema.Instance], [thema.TranslationLacunas], and error
 //
+// If the [WithEnvelope] option is provided, the input is instead validated
+// only against the schema version declared in its envelope.
+//
 // The returned error may be from any of the above steps.
-func NewUntypedMux(sch thema.Schema, dec Decoder) UntypedMux {
+func NewUntypedMux(sch thema.Schema, dec Decoder, opts ...MuxOption) UntypedMux {
 	ctx := sch.Lineage().UnwrapCUE().Context()
+	cfg := newMuxConfig(opts)
 	// Prepare no-match error string once for reuse
 	vstring := allvstr(sch)
 
@@ -38,6 +42,17 @@ func NewUntypedMux(sch thema.Schema, dec Decoder) UntypedMux {
 			return nil, nil, err
 		}
 
+		if cfg.env != nil {
+			inst, err := cfg.env.Instance(sch.Lineage(), v)
+			if err != nil {
+				return nil, nil, err
+			}
+			if inst.Schema().Version() == sch.Version() {
+				return inst, nil, nil
+			}
+			return inst.TranslateE(sch.Version())
+		}
+
 		// Try the given schema first, on the premise that in general it's the
 		// most likely one for an application to encounter
 		tinst, err := sch.Validate(v)
@@ -54,8 +69,7 @@ func NewUntypedMux(sch thema.Schema, dec Decoder) UntypedMux {
 			}
 
 			if inst, ierr := isch.Validate(v); ierr == nil {
-				trinst, lac := inst.Translate(sch.Version())
-				return trinst, lac, nil
+				return inst.TranslateE(sch.Version())
 			}
 		}
 
@@ -73,19 +87,30 @@ type ByteMux func(b []byte) ([]byte, thema.TranslationLacunas, error)
 //
 //   - Decode the input []byte using the provided [Endec], then
 //   - Pass the result to [thema.Schema.Validate], then
-//   - Call [thema.Instance.Translate] on the result, to the version of the provided [thema.Schema], then
+//   - Call [thema.Instance.TranslateE] on the result, to the version of the provided [thema.Schema], then
 //   - Encode the resulting [thema.Instance] to a []byte, then
 //   - Return the resulting []byte, [thema.TranslationLacunas], and error
 //
+// If the [WithEnvelope] option is provided, the input is instead validated
+// only against the schema version declared in its envelope, and the output
+// is enveloped in the same way.
+//
 // The returned error may be from any of the above steps.
-func NewByteMux(sch thema.Schema, end Endec) ByteMux {
-	f := NewUntypedMux(sch, end)
+func NewByteMux(sch thema.Schema, end Endec, opts ...MuxOption) ByteMux {
+	cfg := newMuxConfig(opts)
+	f := NewUntypedMux(sch, end, opts...)
 	return func(b []byte) ([]byte, thema.TranslationLacunas, error) {
 		ti, lac, err := f(b)
 		if err != nil {
 			return nil, lac, err
 		}
-		ob, err := end.Encode(ti.UnwrapCUE())
+		out := ti.UnwrapCUE()
+		if cfg.env != nil {
+			if out, err = ti.Envelope(*cfg.env); err != nil {
+				return nil, lac, err
+			}
+		}
+		ob, err := end.Encode(out)
 		return ob, lac, err
 	}
 }
@@ -101,13 +126,16 @@ type ValueMux[T thema.Assignee] func(b []byte) (T, thema.TranslationLacunas, err
 //
 //   - Decode the input []byte using the provided [Decoder], then
 //   - Pass the result to [thema.TypedSchema.ValidateTyped], then
-//   - Call [thema.Instance.Translate] on the result, to the version of the provided [thema.TypedSchema], then
+//   - Call [thema.Instance.TranslateE] on the result, to the version of the provided [thema.TypedSchema], then
 //   - Populate an instance of T by calling [thema.TypedInstance.Value] on the result, then
 //   - Return the resulting T, [thema.TranslationLacunas], and error
 //
+// If the [WithEnvelope] option is provided, the input is instead validated
+// only against the schema version declared in its envelope.
+//
 // The returned error may be from any of the above steps.
-func NewValueMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder) ValueMux[T] {
-	f := NewTypedMux[T](sch, dec)
+func NewValueMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder, opts ...MuxOption) ValueMux[T] {
+	f := NewTypedMux[T](sch, dec, opts...)
 	return func(b []byte) (T, thema.TranslationLacunas, error) {
 		ti, lac, err := f(b)
 		if err != nil {
@@ -128,12 +156,16 @@ type TypedMux[T thema.Assignee] func(b []byte) (*thema.TypedInstance[T], thema.T
 //
 //   - Decode the input []byte using the provided [Decoder], then
 //   - Pass the result to [thema.TypedSchema.ValidateTyped], then
-//   - Call [thema.Instance.Translate] on the result, to the version of the provided [thema.TypedSchema], then
+//   - Call [thema.Instance.TranslateE] on the result, to the version of the provided [thema.TypedSchema], then
 //   - Return the resulting [thema.TypedInstance], [thema.TranslationLacunas], and error
 //
+// If the [WithEnvelope] option is provided, the input is instead validated
+// only against the schema version declared in its envelope.
+//
 // The returned error may be from any of the above steps.
-func NewTypedMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder) TypedMux[T] {
+func NewTypedMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder, opts ...MuxOption) TypedMux[T] {
 	ctx := sch.Lineage().UnwrapCUE().Context()
+	cfg := newMuxConfig(opts)
 	// Prepare no-match error string once for reuse
 	vstring := allvstr(sch)
 
@@ -144,6 +176,24 @@ func NewTypedMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder) TypedM
 			return nil, nil, err
 		}
 
+		if cfg.env != nil {
+			inst, err := cfg.env.Instance(sch.Lineage(), v)
+			if err != nil {
+				return nil, nil, err
+			}
+			var lac thema.TranslationLacunas
+			if inst.Schema().Version() != sch.Version() {
+				if inst, lac, err = inst.TranslateE(sch.Version()); err != nil {
+					return nil, lac, err
+				}
+			}
+			tinst, err := thema.BindInstanceType(inst, sch)
+			if err != nil {
+				panic(fmt.Errorf("unreachable, instance type should always be bindable: %w", err))
+			}
+			return tinst, lac, nil
+		}
+
 		// Try the given schema first, on the premise that in general it's the
 		// most likely one for an application to encounter
 		tinst, err := sch.ValidateTyped(v)
@@ -160,7 +210,10 @@ func NewTypedMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder) TypedM
 			}
 
 			if inst, ierr := isch.Validate(v); ierr == nil {
-				trinst, lac := inst.Translate(sch.Version())
+				trinst, lac, err := inst.TranslateE(sch.Version())
+				if err != nil {
+					return nil, lac, err
+				}
 				tinst, err := thema.BindInstanceType(trinst, sch)
 				if err != nil {
 					panic(fmt.Errorf("unreachable, instance type should always be bindable: %w", err))
@@ -173,6 +226,37 @@ func NewTypedMux[T thema.Assignee](sch thema.TypedSchema[T], dec Decoder) TypedM
 	}
 }
 
+// A MuxOption defines optional behavior for the version multiplexers created
+// by this package.
+type MuxOption muxOption
+
+// Internal representation of MuxOption.
+type muxOption func(c *muxConfig)
+
+type muxConfig struct {
+	env *thema.Envelope
+}
+
+func newMuxConfig(opts []MuxOption) *muxConfig {
+	cfg := &muxConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithEnvelope indicates that inputs to the mux are self-describing, carrying
+// their lineage name and schema version in the provided [thema.Envelope].
+//
+// Rather than trying to validate inputs against every schema in the lineage
+// to find their version, the mux validates only against the declared version,
+// failing if the input is not a valid instance of that schema.
+func WithEnvelope(env thema.Envelope) MuxOption {
+	return func(c *muxConfig) {
+		c.env = &env
+	}
+}
+
 func allvstr(sch thema.Schema) string {
 	var vl []string
 	for isch := thema.SchemaP(sch.Lineage(), thema.SV(0, 0)); isch != nil; isch = isch.Successor() {
@@ -8,8 +8,8 @@ import (
 
 	"cuelang.org/go/cue/cuecontext"
 	"github.com/grafana/thema"
+	terrors "github.com/grafana/thema/errors"
 	"github.com/grafana/thema/exemplars"
-	"github.com/grafana/thema/internal/envvars"
 	"github.com/stretchr/testify/require"
 )
 
@@ -219,9 +219,6 @@ func checkSpectrumAcrossMuxers[T thema.Assignee](t *testing.T, clin thema.Conver
 
 		t.Run(fmt.Sprintf("%v->%v", spec.in.v, v), func(t *testing.T) {
 			t.Parallel()
-			if !envvars.ReverseTranslate && v.Less(spec.in.v) {
-				t.Skip("thema does not yet support reverse translation")
-			}
 
 			// Always do the untyped muxers
 			t.Run("UntypedMux", func(T *testing.T) {
@@ -283,3 +280,46 @@ func handleLE(t *testing.T, im image, lac thema.TranslationLacunas, err error) {
 	// TODO For now, pass this off to require. Totally needs special handling, though
 	// require.EqualValues(t, im.lac, lac)
 }
+
+func TestMuxersWithEnvelope(t *testing.T) {
+	rt := thema.NewRuntime(cuecontext.New())
+	lins := setupRenameLins(t, rt)
+	endec := NewJSONEndec("test")
+	opt := WithEnvelope(thema.Envelope{})
+
+	in := []byte(`{
+		"lineage": "rename",
+		"version": "0.0",
+		"data": { "before": "renamedstr", "unchanged": "unchanged str val" }
+	}`)
+
+	um := NewUntypedMux(thema.SchemaP(lins.second, thema.SV(1, 0)), endec, opt)
+	inst, _, err := um(in)
+	require.NoError(t, err)
+	require.Equal(t, thema.SV(1, 0), inst.Schema().Version())
+
+	bm := NewByteMux(thema.SchemaP(lins.second, thema.SV(1, 0)), endec, opt)
+	out, _, err := bm(in)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"lineage": "rename",
+		"version": "1.0",
+		"data": { "after": "renamedstr", "unchanged": "unchanged str val" }
+	}`, string(out))
+
+	vm := NewValueMux(lins.second.TypedSchema(), endec, opt)
+	val, _, err := vm(in)
+	require.NoError(t, err)
+	require.Equal(t, "renamedstr", val.After)
+
+	// The declared version is verified, not scanned for
+	_, _, err = um([]byte(`{
+		"lineage": "rename",
+		"version": "1.0",
+		"data": { "before": "renamedstr", "unchanged": "unchanged str val" }
+	}`))
+	require.ErrorIs(t, err, terrors.ErrNotAnInstance)
+
+	_, _, err = um([]byte(`{ "before": "renamedstr", "unchanged": "unchanged str val" }`))
+	require.ErrorIs(t, err, terrors.ErrInvalidEnvelope)
+}